@@ -0,0 +1,88 @@
+// Command dbunit manages the test databases created by dbunit.
+//
+//	dbunit list [-driver mysql] [-dsn root:123456@tcp(127.0.0.1:3306)/]
+//	dbunit reap [-driver mysql] [-dsn ...] [-older-than 24h] [-dry-run]
+//
+// The driver and the data source default to dbunit.yml and the DBUNIT_*
+// environment variables, like in the tests.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/goapt/dbunit"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const usage = `usage: dbunit <command> [flags]
+
+commands:
+  list    list the test databases
+  reap    drop the test databases older than -older-than
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	var (
+		config    dbunit.Config
+		olderThan time.Duration
+		dryRun    bool
+	)
+	fs := flag.NewFlagSet("dbunit "+args[0], flag.ContinueOnError)
+	fs.StringVar(&config.Driver, "driver", "", "database driver, mysql, postgres or sqlite3")
+	fs.StringVar(&config.DSN, "dsn", "", "data source name without database")
+
+	switch args[0] {
+	case "list":
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		databases, err := dbunit.ListDatabases(config)
+		if err != nil {
+			return err
+		}
+		for _, db := range databases {
+			fmt.Fprintf(stdout, "%s\t%s\n", db.Name, db.Created.Format(time.RFC3339))
+		}
+		return nil
+	case "reap":
+		fs.DurationVar(&olderThan, "older-than", 24*time.Hour, "drop the databases created before this duration")
+		fs.BoolVar(&dryRun, "dry-run", false, "only print the databases that would be dropped")
+		if err := fs.Parse(args[1:]); err != nil {
+			return err
+		}
+		if dryRun {
+			databases, err := dbunit.ExpiredDatabases(config, olderThan)
+			if err != nil {
+				return err
+			}
+			for _, db := range databases {
+				fmt.Fprintln(stdout, db.Name)
+			}
+			return nil
+		}
+		dropped, err := dbunit.Reap(config, olderThan)
+		for _, name := range dropped {
+			fmt.Fprintln(stdout, name)
+		}
+		return err
+	}
+	return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	old := fmt.Sprintf("test_%d_1", time.Now().Add(-48*time.Hour).UnixNano())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, old+".db"), nil, 0644))
+	flags := []string{"-driver", "sqlite3", "-dsn", dir}
+
+	var out bytes.Buffer
+	require.NoError(t, run(append([]string{"list"}, flags...), &out))
+	assert.Contains(t, out.String(), old)
+
+	out.Reset()
+	require.NoError(t, run(append([]string{"reap", "-dry-run"}, flags...), &out))
+	assert.Equal(t, old+"\n", out.String())
+	assert.FileExists(t, filepath.Join(dir, old+".db"))
+
+	out.Reset()
+	require.NoError(t, run(append([]string{"reap", "-older-than", "1h"}, flags...), &out))
+	assert.Equal(t, old+"\n", out.String())
+	assert.NoFileExists(t, filepath.Join(dir, old+".db"))
+
+	assert.Error(t, run([]string{"drop"}, &out))
+}
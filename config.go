@@ -0,0 +1,309 @@
+package dbunit
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// 测试结束后的清理策略
+const (
+	// CleanupAlways 总是删除测试库
+	CleanupAlways = "always"
+	// CleanupOnSuccess 仅在测试通过时删除测试库，失败的测试库保留用于排查
+	CleanupOnSuccess = "on-success"
+	// CleanupNever 从不删除测试库
+	CleanupNever = "never"
+)
+
+// configFileNames are looked up from the package directory up to the module root
+var configFileNames = []string{"dbunit.yml", "dbunit.yaml"}
+
+// Config 测试数据库的连接配置
+type Config struct {
+	// Driver 数据库驱动名，支持 mysql、postgres、pgx、sqlite3、sqlite
+	Driver string `yaml:"driver"`
+	// DSN 不包含数据库名的连接串，SQLite 下为存放数据库文件的目录或者 ":memory:"
+	DSN string `yaml:"dsn"`
+	// Params 追加到测试库连接串上的参数，优先级高于下面的字段
+	Params map[string]string `yaml:"params"`
+	// TimeZone 连接使用的时区，同时也是 fixtures 中日期的默认时区
+	TimeZone string `yaml:"timezone"`
+	// Collation 连接的字符序，仅 MySQL 有效
+	Collation string `yaml:"collation"`
+	// SQLMode 连接的 sql_mode，仅 MySQL 有效
+	SQLMode string `yaml:"sql_mode"`
+	// Schema 默认的 schema 文件，调用 Run、NewDatabase 时 schema 为空字符串时使用
+	Schema string `yaml:"schema"`
+	// Fixtures 默认的 fixtures 目录，未指定 fixtures 时使用，默认为 schema 同级的 fixtures 目录
+	Fixtures string `yaml:"fixtures"`
+	// Cleanup 测试结束后的清理策略，默认 always
+	Cleanup string `yaml:"cleanup"`
+	// NoTemplate 为 true 时关闭 schema 模板库，每个测试库都完整导入一次 schema，
+	// nil 时沿用低优先级的配置，可以用 Bool(false) 覆盖 dbunit.yml 中的 true
+	NoTemplate *bool `yaml:"no_template"`
+	// KeepFailed 为 true 时保留失败测试的测试库并重命名为 test_failed_<测试名>_<时间>_<进程号>_<序号>，
+	// nil 时沿用低优先级的配置，也可以通过 go test 的 -dbunit.keep-failed 参数开启
+	KeepFailed *bool `yaml:"keep_failed"`
+	// ReapAfter 大于 0 时，进程创建第一个测试库前删除服务器上创建时间早于该时长的测试库，如 24h
+	ReapAfter time.Duration `yaml:"reap_after"`
+}
+
+var builtinConfig = Config{
+	Driver:   "mysql",
+	DSN:      "root:123456@tcp(127.0.0.1:3306)/",
+	TimeZone: "Asia/Shanghai",
+	Cleanup:  CleanupAlways,
+}
+
+// keepFailedFlag is parsed by go test: go test ./... -dbunit.keep-failed
+var keepFailedFlag = flag.Bool("dbunit.keep-failed", false, "keep and rename the test databases of failed tests")
+
+var (
+	// environConfig is read from dbunit.yml and the DBUNIT_* environment variables
+	environConfig = builtinConfig
+	defaultConfig = builtinConfig
+	// configErr reports a broken dbunit.yml or environment when creating a database
+	configErr error
+)
+
+func init() {
+	wd, _ := os.Getwd()
+	environConfig, configErr = loadConfig(wd, os.Getenv)
+	defaultConfig = environConfig
+}
+
+// SetConfig 配置单元测试的数据库，未设置的字段沿用 dbunit.yml、环境变量或者默认值
+func SetConfig(c Config) {
+	defaultConfig = c.merge(environConfig)
+}
+
+// SetDatabase 配置单元测试的数据库DSN，SQLite 下为存放数据库文件的目录或者 ":memory:"
+func SetDatabase(dsn string) {
+	defaultConfig.DSN = dsn
+}
+
+// SetDriver 配置单元测试的数据库驱动，支持 mysql、postgres、pgx、sqlite3、sqlite
+func SetDriver(driver string) {
+	defaultConfig.Driver = driver
+}
+
+// Bool 返回 v 的指针，用于设置 Config 中的 NoTemplate、KeepFailed
+func Bool(v bool) *bool {
+	return &v
+}
+
+// merge fills the zero fields of c from base, Params are merged key by key
+func (c Config) merge(base Config) Config {
+	if c.Driver == "" {
+		c.Driver = base.Driver
+	}
+	if c.DSN == "" {
+		c.DSN = base.DSN
+	}
+	if c.TimeZone == "" {
+		c.TimeZone = base.TimeZone
+	}
+	if c.Collation == "" {
+		c.Collation = base.Collation
+	}
+	if c.SQLMode == "" {
+		c.SQLMode = base.SQLMode
+	}
+	if c.Schema == "" {
+		c.Schema = base.Schema
+	}
+	if c.Fixtures == "" {
+		c.Fixtures = base.Fixtures
+	}
+	if c.Cleanup == "" {
+		c.Cleanup = base.Cleanup
+	}
+	if c.ReapAfter == 0 {
+		c.ReapAfter = base.ReapAfter
+	}
+	if c.NoTemplate == nil {
+		c.NoTemplate = base.NoTemplate
+	}
+	if c.KeepFailed == nil {
+		c.KeepFailed = base.KeepFailed
+	}
+	params := make(map[string]string, len(base.Params)+len(c.Params))
+	for k, v := range base.Params {
+		params[k] = v
+	}
+	for k, v := range c.Params {
+		params[k] = v
+	}
+	c.Params = params
+	return c
+}
+
+// location returns the time zone of the configuration
+func (c Config) location() (*time.Location, error) {
+	if c.TimeZone == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(c.TimeZone)
+}
+
+// params returns the connection parameters of the test database
+func (c Config) params(d dialect) url.Values {
+	params := d.params(c)
+	for k, v := range c.Params {
+		params.Set(k, v)
+	}
+	return params
+}
+
+// loadConfig builds the configuration from, in increasing precedence, the
+// built-in defaults, the dbunit.yml found from dir (or named by DBUNIT_CONFIG)
+// and the DBUNIT_* environment variables
+func loadConfig(dir string, getenv func(string) string) (Config, error) {
+	config := builtinConfig
+
+	file := getenv("DBUNIT_CONFIG")
+	if file == "" {
+		file = findConfigFile(dir)
+	}
+	if file != "" {
+		fc, err := readConfigFile(file)
+		if err != nil {
+			return config, err
+		}
+		config = fc.merge(config)
+	}
+
+	ec, err := envConfig(getenv)
+	if err != nil {
+		return config, err
+	}
+	config = ec.merge(config)
+
+	switch config.Cleanup {
+	case CleanupAlways, CleanupOnSuccess, CleanupNever:
+	default:
+		return config, fmt.Errorf("dbunit: unknown cleanup policy %q", config.Cleanup)
+	}
+	return config, nil
+}
+
+// findConfigFile walks up from dir and stops at the directory holding go.mod
+func findConfigFile(dir string) string {
+	for dir != "" {
+		for _, name := range configFileNames {
+			file := filepath.Join(dir, name)
+			if isExists(file) {
+				return file
+			}
+		}
+		if isExists(filepath.Join(dir, "go.mod")) {
+			return ""
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+	return ""
+}
+
+// readConfigFile reads a dbunit.yml, relative paths are relative to the file
+func readConfigFile(file string) (Config, error) {
+	var config Config
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return config, fmt.Errorf("dbunit: could not read config file %s: %w", file, err)
+	}
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return config, fmt.Errorf("dbunit: could not parse config file %s: %w", file, err)
+	}
+
+	dir := filepath.Dir(file)
+	if config.Schema != "" && !filepath.IsAbs(config.Schema) {
+		config.Schema = filepath.Join(dir, config.Schema)
+	}
+	if config.Fixtures != "" && !filepath.IsAbs(config.Fixtures) {
+		config.Fixtures = filepath.Join(dir, config.Fixtures)
+	}
+	return config, nil
+}
+
+func envConfig(getenv func(string) string) (Config, error) {
+	config := Config{
+		Driver:    getenv("DBUNIT_DRIVER"),
+		DSN:       getenv("DBUNIT_DSN"),
+		TimeZone:  getenv("DBUNIT_TIMEZONE"),
+		Collation: getenv("DBUNIT_COLLATION"),
+		SQLMode:   getenv("DBUNIT_SQL_MODE"),
+		Schema:    getenv("DBUNIT_SCHEMA"),
+		Fixtures:  getenv("DBUNIT_FIXTURES"),
+		Cleanup:   getenv("DBUNIT_CLEANUP"),
+	}
+
+	if v := getenv("DBUNIT_NO_TEMPLATE"); v != "" {
+		noTemplate, err := strconv.ParseBool(v)
+		if err != nil {
+			return config, fmt.Errorf("dbunit: invalid DBUNIT_NO_TEMPLATE: %w", err)
+		}
+		config.NoTemplate = &noTemplate
+	}
+
+	if v := getenv("DBUNIT_KEEP_FAILED"); v != "" {
+		keepFailed, err := strconv.ParseBool(v)
+		if err != nil {
+			return config, fmt.Errorf("dbunit: invalid DBUNIT_KEEP_FAILED: %w", err)
+		}
+		config.KeepFailed = &keepFailed
+	}
+
+	if v := getenv("DBUNIT_REAP_AFTER"); v != "" {
+		reapAfter, err := time.ParseDuration(v)
+		if err != nil {
+			return config, fmt.Errorf("dbunit: invalid DBUNIT_REAP_AFTER: %w", err)
+		}
+		config.ReapAfter = reapAfter
+	}
+
+	// DBUNIT_PARAMS uses the query string format: multiStatements=true&timeout=5s
+	if params := getenv("DBUNIT_PARAMS"); params != "" {
+		values, err := url.ParseQuery(params)
+		if err != nil {
+			return config, fmt.Errorf("dbunit: invalid DBUNIT_PARAMS: %w", err)
+		}
+		config.Params = make(map[string]string, len(values))
+		for k := range values {
+			config.Params[k] = values.Get(k)
+		}
+	}
+	return config, nil
+}
+
+// noTemplate reports whether the schema is imported into every database
+func (c Config) noTemplate() bool {
+	return c.NoTemplate != nil && *c.NoTemplate
+}
+
+// keepFailed reports whether the database of a failed test must be renamed and kept
+func (c Config) keepFailed() bool {
+	return c.KeepFailed != nil && *c.KeepFailed || *keepFailedFlag
+}
+
+// keep reports whether the database must survive the end of the test
+func (c Config) keep(failed bool) bool {
+	switch c.Cleanup {
+	case CleanupNever:
+		return true
+	case CleanupOnSuccess:
+		return failed
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package dbunit
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_merge(t *testing.T) {
+	base := Config{
+		Driver:   "mysql",
+		DSN:      "root:123456@tcp(127.0.0.1:3306)/",
+		TimeZone: "Asia/Shanghai",
+		Params:   map[string]string{"multiStatements": "true", "timeout": "1s"},
+	}
+	c := Config{TimeZone: "UTC", Params: map[string]string{"timeout": "5s"}}.merge(base)
+
+	assert.Equal(t, "mysql", c.Driver)
+	assert.Equal(t, "root:123456@tcp(127.0.0.1:3306)/", c.DSN)
+	assert.Equal(t, "UTC", c.TimeZone)
+	assert.Equal(t, map[string]string{"multiStatements": "true", "timeout": "5s"}, c.Params)
+}
+
+func TestConfig_DSN(t *testing.T) {
+	d := &mysqlDialect{}
+	c := builtinConfig
+	assert.Equal(t, "root:123456@tcp(127.0.0.1:3306)/test_1?charset=utf8mb4&loc=Asia%2FShanghai&parseTime=True",
+		d.dsn(c.DSN, "test_1", c.params(d)))
+
+	c = Config{
+		TimeZone:  "UTC",
+		Collation: "utf8mb4_bin",
+		SQLMode:   "TRADITIONAL",
+		Params:    map[string]string{"interpolateParams": "true", "charset": "utf8"},
+	}.merge(builtinConfig)
+	assert.Equal(t, "root:123456@tcp(127.0.0.1:3306)/test_1?charset=utf8&collation=utf8mb4_bin&interpolateParams=true&loc=UTC&parseTime=True&sql_mode=%27TRADITIONAL%27",
+		d.dsn(c.DSN, "test_1", c.params(d)))
+}
+
+func TestSetConfig(t *testing.T) {
+	config := defaultConfig
+	t.Cleanup(func() {
+		defaultConfig = config
+	})
+
+	SetConfig(Config{DSN: "root:root@tcp(127.0.0.1:3306)/"})
+	assert.Equal(t, "mysql", defaultConfig.Driver)
+	assert.Equal(t, "Asia/Shanghai", defaultConfig.TimeZone)
+
+	SetDatabase("root@tcp(database:3306)/")
+	assert.Equal(t, "root@tcp(database:3306)/", defaultConfig.DSN)
+}
+
+func TestDBUnit_NewDatabaseWithConfig(t *testing.T) {
+	New(t, func(d *DBUnit) {
+		db := d.NewDatabaseWithConfig(Config{Driver: "sqlite3", DSN: t.TempDir(), TimeZone: "UTC"},
+			"testdata/sqlite/schema.sql", "testdata/fixtures/documents.yml")
+
+		var createdAt time.Time
+		err := db.QueryRow("select created_at from documents where id = 1").Scan(&createdAt)
+		assert.NoError(t, err)
+		assert.Equal(t, time.UTC, createdAt.Location())
+		assert.Equal(t, time.Date(2018, 1, 28, 4, 15, 14, 0, time.UTC), createdAt)
+	})
+}
+
+func writeFile(t *testing.T, file, content string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+}
+
+func TestLoadConfig(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "go.mod"), "module example\n")
+	writeFile(t, filepath.Join(root, "dbunit.yml"), `driver: sqlite3
+dsn: ":memory:"
+timezone: UTC
+schema: testdata/schema.sql
+fixtures: testdata/fixtures
+cleanup: on-success
+reap_after: 12h
+no_template: true
+keep_failed: true
+params:
+  _busy_timeout: "5000"
+`)
+	pkg := filepath.Join(root, "internal", "store")
+	require.NoError(t, os.MkdirAll(pkg, 0755))
+
+	env := map[string]string{}
+	getenv := func(k string) string {
+		return env[k]
+	}
+
+	t.Run("config file", func(t *testing.T) {
+		c, err := loadConfig(pkg, getenv)
+		require.NoError(t, err)
+		assert.Equal(t, "sqlite3", c.Driver)
+		assert.Equal(t, ":memory:", c.DSN)
+		assert.Equal(t, "UTC", c.TimeZone)
+		assert.Equal(t, filepath.Join(root, "testdata", "schema.sql"), c.Schema)
+		assert.Equal(t, filepath.Join(root, "testdata", "fixtures"), c.Fixtures)
+		assert.Equal(t, CleanupOnSuccess, c.Cleanup)
+		assert.Equal(t, 12*time.Hour, c.ReapAfter)
+		assert.True(t, c.noTemplate())
+		assert.True(t, c.KeepFailed != nil && *c.KeepFailed)
+		assert.Equal(t, map[string]string{"_busy_timeout": "5000"}, c.Params)
+	})
+
+	t.Run("environment overrides config file", func(t *testing.T) {
+		env = map[string]string{
+			"DBUNIT_DSN":         "/tmp/dbunit",
+			"DBUNIT_CLEANUP":     "never",
+			"DBUNIT_PARAMS":      "_busy_timeout=100&cache=private",
+			"DBUNIT_REAP_AFTER":  "30m",
+			"DBUNIT_NO_TEMPLATE": "false",
+			"DBUNIT_KEEP_FAILED": "false",
+		}
+		c, err := loadConfig(pkg, getenv)
+		require.NoError(t, err)
+		assert.Equal(t, "sqlite3", c.Driver)
+		assert.Equal(t, "/tmp/dbunit", c.DSN)
+		assert.Equal(t, CleanupNever, c.Cleanup)
+		assert.Equal(t, 30*time.Minute, c.ReapAfter)
+		assert.False(t, c.noTemplate())
+		assert.Equal(t, Bool(false), c.KeepFailed)
+		assert.Equal(t, map[string]string{"_busy_timeout": "100", "cache": "private"}, c.Params)
+
+		// the code overrides the environment the same way
+		c = Config{NoTemplate: Bool(true)}.merge(c)
+		assert.True(t, c.noTemplate())
+		assert.Equal(t, Bool(false), c.KeepFailed)
+	})
+
+	t.Run("DBUNIT_CONFIG", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), "ci.yml")
+		writeFile(t, other, "driver: postgres\ndsn: postgres://postgres@127.0.0.1/\n")
+		env = map[string]string{"DBUNIT_CONFIG": other}
+		c, err := loadConfig(pkg, getenv)
+		require.NoError(t, err)
+		assert.Equal(t, "postgres", c.Driver)
+		assert.Equal(t, "Asia/Shanghai", c.TimeZone)
+	})
+
+	t.Run("invalid cleanup", func(t *testing.T) {
+		env = map[string]string{"DBUNIT_CLEANUP": "sometimes"}
+		_, err := loadConfig(pkg, getenv)
+		assert.Error(t, err)
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		other := filepath.Join(t.TempDir(), "dbunit.yml")
+		writeFile(t, other, "dns: root@tcp(127.0.0.1:3306)/\n")
+		env = map[string]string{"DBUNIT_CONFIG": other}
+		_, err := loadConfig(pkg, getenv)
+		assert.Error(t, err)
+	})
+}
+
+func Test_findConfigFile(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "dbunit.yml"), "driver: mysql\n")
+	writeFile(t, filepath.Join(root, "module", "go.mod"), "module example\n")
+	pkg := filepath.Join(root, "module", "pkg")
+	require.NoError(t, os.MkdirAll(pkg, 0755))
+
+	// the search stops at the module root
+	assert.Equal(t, "", findConfigFile(pkg))
+	assert.Equal(t, filepath.Join(root, "dbunit.yml"), findConfigFile(root))
+}
+
+func TestConfig_keep(t *testing.T) {
+	assert.False(t, Config{Cleanup: CleanupAlways}.keep(true))
+	assert.False(t, Config{Cleanup: CleanupOnSuccess}.keep(false))
+	assert.True(t, Config{Cleanup: CleanupOnSuccess}.keep(true))
+	assert.True(t, Config{Cleanup: CleanupNever}.keep(false))
+}
+
+func TestRunDefaultSchema(t *testing.T) {
+	config := defaultConfig
+	t.Cleanup(func() {
+		defaultConfig = config
+	})
+	SetConfig(Config{Driver: "sqlite3", DSN: t.TempDir(), Schema: "testdata/sqlite/schema.sql", Fixtures: "testdata/custom"})
+
+	Run(t, "", func(t *testing.T, db *sql.DB) {
+		var ct int
+		require.NoError(t, db.QueryRow("select count(1) from custom").Scan(&ct))
+		assert.Equal(t, 1, ct)
+	})
+}
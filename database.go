@@ -1,74 +1,134 @@
 package dbunit
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
 	"regexp"
+	"strings"
 	"sync/atomic"
 	"time"
 )
 
-var (
-	defaultTestDSN         = "root:123456@tcp(127.0.0.1:3306)/"
-	createTableRegex       = regexp.MustCompile(`(?isU)CREATE TABLE\s+.*;`)
-	id               int32 = 0
-)
+var id int32 = 0
 
-func init() {
-	if os.Getenv("DRONE") == "true" {
-		SetDatabase("root:123456@tcp(database:3306)/")
-	}
+// maxNameLength is the longest identifier PostgreSQL keeps, MySQL allows 64
+const maxNameLength = 63
 
-	if os.Getenv("CI") == "true" {
-		SetDatabase("root:root@tcp(127.0.0.1:3306)/")
-	}
+var nameRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+type database struct {
+	Name    string
+	config  Config
+	dialect dialect
+	db      *sql.DB
 }
 
-// SetDatabase 配置单元测试的数据库DSN
-func SetDatabase(dsn string) {
-	defaultTestDSN = dsn
+func newDatabase(config Config, schema, testName string) (*database, error) {
+	return newDatabaseWithName(config, newDatabaseName(testName), schema)
 }
 
-type database struct {
-	Name   string
-	source string
-	db     *sql.DB
+// newDatabaseName returns test_<test name>_<nanoseconds>_<sequence>, the
+// test name is sanitized and truncated to fit the identifier length limit
+func newDatabaseName(testName string) string {
+	suffix := fmt.Sprintf("%d_%d", time.Now().UnixNano(), atomic.AddInt32(&id, 1))
+	name := sanitizeName(testName, maxNameLength-len("test__")-len(suffix))
+	if name == "" {
+		return "test_" + suffix
+	}
+	return "test_" + name + "_" + suffix
 }
 
-func newDatabase(schema string) *database {
-	atomic.AddInt32(&id, 1)
-	name := "test_" + fmt.Sprintf("%d_%d", time.Now().UnixNano(), id)
-	return newDatabaseWithName(name, schema)
+// failedDatabaseName is the name a database of a failed test is kept under,
+// the process id and the sequence tell apart the runs of -count=N and the
+// tests of the same name in other packages
+func failedDatabaseName(testName string, now time.Time) string {
+	prefix := "test_failed_"
+	suffix := fmt.Sprintf("_%s_%d_%d", now.UTC().Format("20060102150405"), os.Getpid(), atomic.AddInt32(&id, 1))
+	name := sanitizeName(testName, maxNameLength-len(prefix)-len(suffix))
+	if name == "" {
+		name = "test"
+	}
+	return prefix + name + suffix
 }
 
-func newDatabaseWithName(name string, schema string) *database {
-	db := &database{Name: name, source: defaultTestDSN}
-	err := db.connection()
+// sanitizeName turns s into a lower case identifier of at most max bytes,
+// unquoted identifiers are folded to lower case by PostgreSQL
+func sanitizeName(s string, max int) string {
+	s = strings.Trim(nameRegex.ReplaceAllString(strings.ToLower(s), "_"), "_")
+	if len(s) > max {
+		s = strings.TrimRight(s[:max], "_")
+	}
+	return s
+}
 
+func newDatabaseWithName(config Config, name string, schema string) (*database, error) {
+	dialect, err := dialectFor(config.Driver)
 	if err != nil {
-		panic("test mysql connection fail," + err.Error())
+		return nil, err
+	}
+	sweep(config)
+
+	db := &database{Name: name, config: config, dialect: dialect}
+	err = db.connection()
+	if err != nil {
+		return nil, fmt.Errorf("test database connection fail,%w", err)
+	}
+
+	if !config.noTemplate() && !isSQLiteMemory(dialect, config) {
+		err = db.cloneFrom(schema)
+		if err == nil {
+			return db, nil
+		}
+		if err != errCloneUnsupported {
+			db.db.Close()
+			return nil, err
+		}
 	}
 
 	err = db.create()
 	if err != nil {
-		panic("test mysql create database fail," + err.Error())
+		db.db.Close()
+		return nil, fmt.Errorf("test database create database fail,%w", err)
 	}
 
 	err = db.Import(schema)
 	if err != nil {
-		panic(err)
+		// do not leave a half imported database behind
+		_ = db.Drop()
+		return nil, err
 	}
-	return db
+	return db, nil
+}
+
+// cloneFrom creates the database as a copy of the template built from schema
+func (d *database) cloneFrom(schema string) error {
+	if !isExists(schema) {
+		return fmt.Errorf("sql file not found:%s", schema)
+	}
+
+	tpl, err := templateFor(d.config, schema)
+	if err != nil {
+		return err
+	}
+
+	err = tpl.clone(d)
+	if err != nil && err != errCloneUnsupported {
+		_ = d.dialect.dropDatabase(d.db, d.Name)
+		return fmt.Errorf("test database clone database fail,%w", err)
+	}
+	return err
 }
 
 func (d *database) DSN() string {
-	return defaultTestDSN + d.Name + "?charset=utf8mb4&parseTime=True&loc=Asia%2FShanghai"
+	return d.dialect.dsn(d.config.DSN, d.Name, d.config.params(d.dialect))
 }
 
 func (d *database) connection() error {
-	db, err := sql.Open("mysql", d.source)
+	db, err := sql.Open(d.config.Driver, d.dialect.serverDSN(d.config.DSN, d.Name))
 	if err != nil {
 		return err
 	}
@@ -77,9 +137,7 @@ func (d *database) connection() error {
 }
 
 func (d *database) Drop() error {
-	query := fmt.Sprintf("DROP DATABASE IF EXISTS %s", d.Name)
-	defaultLog.Print(query)
-	_, err := d.db.Exec(query)
+	err := d.dialect.dropDatabase(d.db, d.Name)
 	if err != nil {
 		return err
 	}
@@ -87,11 +145,17 @@ func (d *database) Drop() error {
 	return nil
 }
 
+func (d *database) rename(name string) error {
+	defaultLog.Print("Rename database " + d.Name + " to " + name)
+	if err := d.dialect.renameDatabase(d.db, d.config, d.Name, name); err != nil {
+		return err
+	}
+	d.Name = name
+	return nil
+}
+
 func (d *database) create() error {
-	query := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", d.Name)
-	defaultLog.Print(query)
-	_, err := d.db.Exec(query)
-	return err
+	return d.dialect.createDatabase(d.db, d.Name)
 }
 
 func (d *database) Import(schema string) error {
@@ -105,25 +169,55 @@ func (d *database) Import(schema string) error {
 		return err
 	}
 
-	querys := createTableRegex.FindAllString(string(content), -1)
+	statements, err := splitScript(string(content))
+	if err != nil {
+		return fmt.Errorf("dbunit: could not parse schema %s: %w", schema, err)
+	}
 
-	var results []sql.Result
+	db, err := sql.Open(d.config.Driver, d.DSN())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
 
-	db, err := sql.Open("mysql", d.DSN())
+	// session state set by the script (SET FOREIGN_KEY_CHECKS, SET NAMES...)
+	// must hold for the following statements, so they share one connection
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
 	if err != nil {
 		return err
 	}
+	defer conn.Close()
 
 	defaultLog.Print(fmt.Sprintf("Import schema:%s", schema))
-	for _, query := range querys {
-		defaultLog.Debug(query)
-		if len(query) > 0 {
-			result, err := db.Exec(query)
-			results = append(results, result)
-			if err != nil {
-				return err
+	for _, stmt := range statements {
+		defaultLog.Debug(stmt.query)
+		if _, err := conn.ExecContext(ctx, stmt.query); err != nil {
+			return &ImportError{
+				Err:  err,
+				File: schema,
+				Line: stmt.line,
+				SQL:  stmt.query,
 			}
 		}
 	}
 	return nil
 }
+
+var (
+	dsnPasswordRegex   = regexp.MustCompile(`(?i)(password=)(\S+)`)
+	mysqlPasswordRegex = regexp.MustCompile(`^([^:@/]*):[^@]*@`)
+)
+
+// redactDSN hides the password of a data source name so it can be logged
+func redactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil {
+			return u.Redacted()
+		}
+	}
+	if dsnPasswordRegex.MatchString(dsn) {
+		return dsnPasswordRegex.ReplaceAllString(dsn, "${1}xxxxx")
+	}
+	return mysqlPasswordRegex.ReplaceAllString(dsn, "${1}:xxxxx@")
+}
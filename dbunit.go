@@ -2,7 +2,9 @@ package dbunit
 
 import (
 	"database/sql"
+	"fmt"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -13,28 +15,131 @@ func Run(t *testing.T, schema string, f func(t *testing.T, db *sql.DB), fixtures
 	})
 }
 
+// RunTx 与 Run 相同，但相同 schema 和 fixtures 的测试共用一个测试库，
+// f 收到的 db 绑定在一个事务上，测试结束时回滚。
+// 调用 t.Parallel() 的测试会在同一个库上同时打开多个事务，写同一行时互相等待锁，
+// SQLite 同一时间只允许一个写事务，这类测试不要并行执行
+func RunTx(t *testing.T, schema string, f func(t *testing.T, db *sql.DB), fixtures ...string) {
+	New(t, func(d *DBUnit) {
+		db := d.NewTxDatabase(schema, fixtures...)
+		f(t, db)
+	})
+}
+
 type DBUnit struct {
+	t     testing.TB
 	tests []*Testing
 }
 
+// NewDatabase creates a test database and loads the fixtures into it,
+// the test fails immediately when anything goes wrong
 func (d *DBUnit) NewDatabase(schema string, fixtures ...string) *sql.DB {
-	test := NewTest(schema)
-	if len(fixtures) == 0 {
-		fixtures = append(fixtures, filepath.Join(filepath.Dir(schema), "fixtures"))
+	d.t.Helper()
+	return d.NewDatabaseWithConfig(Config{}, schema, fixtures...)
+}
+
+// NewDatabaseWithConfig is like NewDatabase, the non-zero fields of config
+// override the global configuration for this database only
+func (d *DBUnit) NewDatabaseWithConfig(config Config, schema string, fixtures ...string) *sql.DB {
+	d.t.Helper()
+	return d.newTesting(config, schema, fixtures...).DB()
+}
+
+// NewTesting is like NewDatabase but returns the Testing, e.g. to run
+// subtests with Testing.Subtest
+func (d *DBUnit) NewTesting(schema string, fixtures ...string) *Testing {
+	d.t.Helper()
+	return d.newTesting(Config{}, schema, fixtures...)
+}
+
+func (d *DBUnit) newTesting(config Config, schema string, fixtures ...string) *Testing {
+	d.t.Helper()
+
+	config = config.merge(defaultConfig)
+	if schema == "" {
+		schema = config.Schema
+	}
+	test, err := newTest(config, schema, d.t.Name())
+	if err != nil {
+		d.t.Fatalf("dbunit: create database from schema %s on %s: %v", schema, redactDSN(config.DSN), err)
 	}
-	test.Load(fixtures...)
 	d.tests = append(d.tests, test)
-	return test.DB()
+
+	fixtures = defaultFixtures(config, schema, fixtures)
+	if err := test.LoadE(fixtures...); err != nil {
+		d.t.Fatalf("dbunit: load fixtures %s from schema %s into %s: %v", strings.Join(fixtures, ","), schema, redactDSN(test.DSN()), err)
+	}
+	return test
+}
+
+// NewTxDatabase returns a handle on a test database shared by every call
+// with the same schema and fixtures. Everything done through the handle runs
+// in one transaction rolled back when the test ends, transactions begun on
+// it become savepoints. Statements causing an implicit commit, like DDL in
+// MySQL, break the isolation. Parallel tests hold concurrent transactions on
+// the shared database, they wait for each other's row locks and SQLite lets
+// only one of them write at a time.
+func (d *DBUnit) NewTxDatabase(schema string, fixtures ...string) *sql.DB {
+	d.t.Helper()
+
+	config := defaultConfig
+	if schema == "" {
+		schema = config.Schema
+	}
+	fixtures = defaultFixtures(config, schema, fixtures)
+
+	test, err := sharedTest(config, schema, fixtures)
+	if err != nil {
+		d.t.Fatalf("dbunit: prepare shared database from schema %s with fixtures %s on %s: %v", schema, strings.Join(fixtures, ","), redactDSN(config.DSN), err)
+	}
+
+	db, rollback := test.TxDB()
+	d.t.Cleanup(func() {
+		if err := rollback(); err != nil {
+			d.t.Errorf("dbunit: rollback database %s: %v", test.tdb.Name, err)
+		}
+	})
+	return db
+}
+
+// defaultFixtures returns the fixtures to load when none is given
+func defaultFixtures(config Config, schema string, fixtures []string) []string {
+	if len(fixtures) > 0 {
+		return fixtures
+	}
+	if config.Fixtures != "" {
+		return []string{config.Fixtures}
+	}
+	return []string{filepath.Join(filepath.Dir(schema), "fixtures")}
 }
 
 func (d *DBUnit) drop() {
-	for _, test := range d.tests {
-		test.Drop()
+	failed := d.t.Failed()
+	for i, test := range d.tests {
+		config := test.tdb.config
+		switch {
+		case failed && config.keepFailed():
+			testName := d.t.Name()
+			if len(d.tests) > 1 {
+				testName = fmt.Sprintf("%s_%d", testName, i+1)
+			}
+			if err := test.keepFailed(testName); err != nil {
+				d.t.Logf("dbunit: rename database %s: %v", test.tdb.Name, err)
+			}
+			d.t.Logf("dbunit: keep database %s of the failed test, connect with: %s", test.tdb.Name, test.tdb.dialect.clientCommand(config.DSN, test.tdb.Name))
+		case config.keep(failed):
+			test.close()
+			defaultLog.Print(fmt.Sprintf("Keep database %s, dsn: %s", test.tdb.Name, redactDSN(test.DSN())))
+		default:
+			if err := test.DropE(); err != nil {
+				d.t.Errorf("dbunit: drop database %s: %v", test.tdb.Name, err)
+			}
+		}
 	}
 }
 
-func New(t *testing.T, f func(d *DBUnit)) {
-	dt := &DBUnit{}
+func New(t testing.TB, f func(d *DBUnit)) {
+	dt := &DBUnit{t: t}
 	t.Cleanup(func() {
 		dt.drop()
 	})
@@ -2,9 +2,16 @@ package dbunit
 
 import (
 	"database/sql"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"testing"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestRun(t *testing.T) {
@@ -74,3 +81,73 @@ func TestLoad(t *testing.T) {
 
 	test.Load("testdata/custom")
 }
+
+// fatalTB records the message of Fatalf instead of failing the real test
+type fatalTB struct {
+	testing.TB
+	fatal string
+}
+
+func (f *fatalTB) Helper() {}
+
+func (f *fatalTB) Fatalf(format string, args ...interface{}) {
+	f.fatal = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+func TestDBUnit_NewDatabaseFatal(t *testing.T) {
+	useSQLite(t, t.TempDir())
+	tb := &fatalTB{TB: t}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		d := &DBUnit{t: tb}
+		defer d.drop()
+		d.NewDatabase("testdata/sqlite/schema.sql", "testdata/not_exists.yml")
+	}()
+	wg.Wait()
+
+	assert.Contains(t, tb.fatal, "dbunit: load fixtures testdata/not_exists.yml from schema testdata/sqlite/schema.sql")
+}
+
+// failedTB reports the test as failed and records the logs
+type failedTB struct {
+	testing.TB
+	logs []string
+}
+
+func (f *failedTB) Failed() bool {
+	return true
+}
+
+func (f *failedTB) Logf(format string, args ...interface{}) {
+	f.logs = append(f.logs, fmt.Sprintf(format, args...))
+}
+
+func TestDBUnit_keepFailed(t *testing.T) {
+	dir := t.TempDir()
+	useSQLite(t, dir)
+	tb := &failedTB{TB: t}
+
+	d := &DBUnit{t: tb}
+	d.NewDatabaseWithConfig(Config{KeepFailed: Bool(true)}, "testdata/sqlite/schema.sql", "testdata/fixtures/users.yml")
+	d.drop()
+
+	files, err := filepath.Glob(filepath.Join(dir, "test_failed_testdbunit_keepfailed_*.db"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Len(t, tb.logs, 1)
+	assert.Contains(t, tb.logs[0], "connect with: sqlite3 "+files[0])
+
+	databases, err := ListDatabases(Config{})
+	require.NoError(t, err)
+	var failed []string
+	for _, db := range databases {
+		if db.Failed {
+			failed = append(failed, db.Name)
+		}
+	}
+	assert.Equal(t, []string{strings.TrimSuffix(filepath.Base(files[0]), ".db")}, failed)
+}
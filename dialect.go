@@ -0,0 +1,77 @@
+package dbunit
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+)
+
+// dialect describes how a database server manages the test databases
+type dialect interface {
+	// dsn returns the data source name of database name on the server described by source
+	dsn(source, name string, params url.Values) string
+	// params returns the connection parameters expressing c
+	params(c Config) url.Values
+	// serverDSN returns the data source used to create and drop database name
+	serverDSN(source, name string) string
+	createDatabase(db *sql.DB, name string) error
+	dropDatabase(db *sql.DB, name string) error
+	// cloneDatabase creates database name as a copy of database template,
+	// it returns errCloneUnsupported when the copy would not be faithful
+	cloneDatabase(db *sql.DB, c Config, template, name string) error
+	// renameDatabase renames database name to newName
+	renameDatabase(db *sql.DB, c Config, name, newName string) error
+	// clientCommand returns the command line of the interactive client
+	// connecting to database name
+	clientCommand(source, name string) string
+	// listDatabases lists the databases named test_* on the server of source
+	listDatabases(db *sql.DB, source string) ([]string, error)
+
+	// tableNames lists the tables of the database db is connected to
+	tableNames(db *sql.DB) ([]string, error)
+	quote(name string) string
+	// placeholder returns the bind variable of the n-th (1-based) argument
+	placeholder(n int) string
+	// disableForeignKeys stops tx from checking foreign keys until it ends
+	disableForeignKeys(tx *sql.Tx) error
+	// enableForeignKeys undoes disableForeignKeys for the settings that
+	// outlive tx, it is called before tx ends
+	enableForeignKeys(tx *sql.Tx) error
+}
+
+// checksummer is implemented by the dialects able to checksum tables on the
+// server, which is cheaper than reading the rows back
+type checksummer interface {
+	checksum(db *sql.DB, tables []string) (map[string]string, error)
+}
+
+func dialectFor(driver string) (dialect, error) {
+	switch driver {
+	case "mysql":
+		return &mysqlDialect{}, nil
+	case "postgres", "pgx":
+		return &postgresDialect{}, nil
+	case "sqlite3", "sqlite":
+		return &sqliteDialect{}, nil
+	}
+	return nil, fmt.Errorf("dbunit: unsupported driver %q", driver)
+}
+
+// queryStrings returns the first column of the rows of query
+func queryStrings(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
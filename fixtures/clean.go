@@ -0,0 +1,81 @@
+package fixtures
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// metadataTable is created by dbunit in every test database, it is never cleaned
+const metadataTable = "dbunit_metadata"
+
+// CleanStrategy is how the tables are emptied before loading, see Clean
+type CleanStrategy int
+
+const (
+	// CleanTruncateAll truncates every table of the database
+	CleanTruncateAll CleanStrategy = iota + 1
+	// CleanTruncateFixtures truncates the tables having a fixture file.
+	// PostgreSQL refuses to truncate a table referenced by a foreign key of
+	// a table left out, use CleanDeleteFixtures for such tables
+	CleanTruncateFixtures
+	// CleanDeleteAll deletes the rows of every table, faster than TRUNCATE
+	// on tiny tables
+	CleanDeleteAll
+	// CleanDeleteFixtures deletes the rows of the tables having a fixture file
+	CleanDeleteFixtures
+)
+
+// Clean empties tables before the fixtures are loaded, the excluded tables,
+// like lookup tables seeded by the schema, are left untouched, as is the
+// dbunit_metadata table of dbunit. On MySQL,
+// TRUNCATE commits the load transaction implicitly.
+func Clean(strategy CleanStrategy, exclude ...string) func(*Loader) error {
+	return func(l *Loader) error {
+		if strategy < CleanTruncateAll || strategy > CleanDeleteFixtures {
+			return fmt.Errorf("testfixtures: unknown clean strategy %d", strategy)
+		}
+		l.clean = strategy
+		l.cleanExclude = append(l.cleanExclude, exclude...)
+		return nil
+	}
+}
+
+// tablesToClean returns the tables emptied by the clean strategy
+func (l *Loader) tablesToClean() ([]string, error) {
+	var tables []string
+	switch l.clean {
+	case CleanTruncateAll, CleanDeleteAll:
+		var err error
+		if tables, err = l.helper.tableNames(l.db); err != nil {
+			return nil, fmt.Errorf("testfixtures: could not list tables: %w", err)
+		}
+	case CleanTruncateFixtures, CleanDeleteFixtures:
+		tables = l.fixtureTables()
+	default:
+		return nil, nil
+	}
+
+	excluded := map[string]bool{metadataTable: true}
+	for _, t := range l.cleanExclude {
+		excluded[t] = true
+	}
+	kept := tables[:0]
+	for _, t := range tables {
+		if !excluded[t] {
+			kept = append(kept, t)
+		}
+	}
+	return kept, nil
+}
+
+// cleanTables empties tables inside the load transaction
+func (l *Loader) cleanTables(tx *sql.Tx, tables []string) error {
+	if len(tables) == 0 {
+		return nil
+	}
+	truncate := l.clean == CleanTruncateAll || l.clean == CleanTruncateFixtures
+	if err := l.helper.cleanTables(tx, tables, truncate); err != nil {
+		return fmt.Errorf("testfixtures: could not clean tables: %w", err)
+	}
+	return nil
+}
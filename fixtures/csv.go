@@ -0,0 +1,289 @@
+package fixtures
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// defaultCSVNull is the NULL marker of LOAD DATA and mysqldump --tab
+const defaultCSVNull = `\N`
+
+// csvBatchRows bounds the rows read from a CSV file before they are
+// inserted, so large files are never held in memory
+const csvBatchRows = 1000
+
+// CSVOptions configures the reading of the .csv fixture files
+type CSVOptions struct {
+	// Delimiter separates the fields, ',' when zero
+	Delimiter rune
+	// Null is the field value read as NULL, `\N` when empty
+	Null string
+	// Quote encloses the fields holding delimiters, quotes or new lines, a
+	// quote is escaped by doubling it. '"' when zero, it must be ASCII.
+	Quote rune
+	// LazyQuotes accepts quotes in unquoted fields and non doubled quotes
+	// in quoted fields
+	LazyQuotes bool
+	// Comment starts the lines to skip, none when zero
+	Comment rune
+}
+
+// CSV sets how the .csv fixture files are read. The first row of a CSV file
+// names the columns of the table named after the file, the fields of the
+// other rows are converted to the types of their columns in the database.
+// The rows are read while loading, by batches, and are neither templates
+// nor checked by Validate.
+func CSV(options CSVOptions) func(*Loader) error {
+	return func(l *Loader) error {
+		quote := options.Quote
+		if quote == 0 {
+			quote = '"'
+		}
+		if quote >= utf8.RuneSelf || quote == '\n' || quote == '\r' || quote == options.Comment {
+			return fmt.Errorf("testfixtures: invalid CSV quote %q", quote)
+		}
+		if options.Delimiter == quote || options.Delimiter == '\n' || options.Delimiter == '\r' {
+			return fmt.Errorf("testfixtures: invalid CSV delimiter %q", options.Delimiter)
+		}
+		l.csv = options
+		return nil
+	}
+}
+
+// csvSource is the header of a CSV fixture file
+type csvSource struct {
+	columns []string
+	// types are the columns of the table in the order of the header
+	types []column
+}
+
+func (f *fixtureFile) isCSV() bool {
+	return strings.EqualFold(filepath.Ext(f.fileName), ".csv")
+}
+
+// csvReader reads r with the CSV options. encoding/csv only knows the '"'
+// quote, so another quote is swapped with it in the input and swapped back
+// in the fields by csvField.
+func (l *Loader) csvReader(r io.Reader) *csv.Reader {
+	if q := l.csv.Quote; q != 0 && q != '"' {
+		r = &quoteSwapper{r: r, quote: byte(q)}
+	}
+	reader := csv.NewReader(r)
+	if l.csv.Delimiter != 0 {
+		reader.Comma = l.csv.Delimiter
+	}
+	reader.Comment = l.csv.Comment
+	reader.LazyQuotes = l.csv.LazyQuotes
+	reader.ReuseRecord = true
+	return reader
+}
+
+// csvField undoes the swap of the quotes of csvReader in a field
+func (l *Loader) csvField(field string) string {
+	q := l.csv.Quote
+	if q == 0 || q == '"' || !strings.ContainsAny(field, string([]rune{q, '"'})) {
+		return field
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case q:
+			return '"'
+		case '"':
+			return q
+		}
+		return r
+	}, field)
+}
+
+// quoteSwapper swaps the bytes quote and '"' of r
+type quoteSwapper struct {
+	r     io.Reader
+	quote byte
+}
+
+func (s *quoteSwapper) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	for i, b := range p[:n] {
+		switch b {
+		case s.quote:
+			p[i] = '"'
+		case '"':
+			p[i] = s.quote
+		}
+	}
+	return n, err
+}
+
+// buildCSVSection reads the header of the CSV file f and the types of its columns
+func (l *Loader) buildCSVSection(f *fixtureFile) (*fixtureSection, error) {
+	table := f.fileNameWithoutExtension()
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf(`testfixtures: could not read file "%s": %w`, f.path, err)
+	}
+	defer file.Close()
+
+	header, err := l.csvReader(file).Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("testfixtures: CSV file %s has no header row", f.fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("testfixtures: could not read CSV file %s: %w", f.fileName, err)
+	}
+
+	columns, err := l.helper.columns(l.db, table)
+	if err != nil {
+		return nil, fmt.Errorf("testfixtures: could not read the columns of %s: %w", table, err)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("testfixtures: unknown table %s of CSV file %s", table, f.fileName)
+	}
+
+	src := &csvSource{}
+	for _, name := range header {
+		name = strings.TrimSpace(l.csvField(name))
+		if name == "" || containsString(src.columns, name) {
+			return nil, fmt.Errorf("testfixtures: empty or duplicate column %q in the header of CSV file %s", name, f.fileName)
+		}
+		i := 0
+		for i < len(columns) && columns[i].name != name {
+			i++
+		}
+		if i == len(columns) {
+			return nil, fmt.Errorf("testfixtures: unknown column %s of table %s in the header of CSV file %s", name, table, f.fileName)
+		}
+		src.columns = append(src.columns, name)
+		src.types = append(src.types, columns[i])
+	}
+	return &fixtureSection{file: f, table: table, csv: src}, nil
+}
+
+// readCSV calls fn with the records of the CSV file of section s, by batches
+// of consecutive records starting at index first
+func (l *Loader) readCSV(s *fixtureSection, fn func(first int, records []map[string]interface{}) error) error {
+	file, err := os.Open(s.file.path)
+	if err != nil {
+		return fmt.Errorf(`testfixtures: could not read file "%s": %w`, s.file.path, err)
+	}
+	defer file.Close()
+
+	reader := l.csvReader(file)
+	if _, err := reader.Read(); err != nil {
+		return fmt.Errorf("testfixtures: could not read CSV file %s: %w", s.file.fileName, err)
+	}
+
+	null := l.csv.Null
+	if null == "" {
+		null = defaultCSVNull
+	}
+
+	var (
+		records []map[string]interface{}
+		first   int
+	)
+	for n := 0; ; n++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("testfixtures: could not read CSV file %s: %w", s.file.fileName, err)
+		}
+
+		record := make(map[string]interface{}, len(fields))
+		for i, field := range fields {
+			field = l.csvField(field)
+			if field == null {
+				record[s.csv.columns[i]] = nil
+				continue
+			}
+			v, err := l.coerce(&s.csv.types[i], field)
+			if err != nil {
+				return fmt.Errorf("testfixtures: record %d of CSV file %s, column %s: %w", n, s.file.fileName, s.csv.columns[i], err)
+			}
+			record[s.csv.columns[i]] = v
+		}
+		records = append(records, record)
+
+		if len(records) == csvBatchRows {
+			if err := fn(first, records); err != nil {
+				return err
+			}
+			records, first = nil, n+1
+		}
+	}
+	if len(records) > 0 {
+		return fn(first, records)
+	}
+	return nil
+}
+
+// loadCSV inserts the records of the CSV file of section s
+func (l *Loader) loadCSV(tx *sql.Tx, s *fixtureSection) error {
+	return l.readCSV(s, func(first int, records []map[string]interface{}) error {
+		inserts, err := l.buildInsertSQL(s.file.strategy, s.table, recordGroup{
+			first:   first,
+			columns: s.csv.columns,
+			records: records,
+		})
+		if err != nil {
+			return err
+		}
+		for _, insert := range inserts {
+			if err := l.exec(tx, s.file, insert); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// coerce converts the CSV field s to the type of column c
+func (l *Loader) coerce(c *column, s string) (interface{}, error) {
+	switch dt := c.dataType; {
+	case intBits[dt] > 0:
+		if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return i, nil
+		}
+		if c.unsigned {
+			if u, err := strconv.ParseUint(s, 10, 64); err == nil {
+				return u, nil
+			}
+		}
+		// MySQL declares BOOLEAN columns as tinyint(1)
+		if b, err := strconv.ParseBool(s); err == nil && intBits[dt] == 8 {
+			if b {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}
+		return nil, fmt.Errorf("%q is not an integer", s)
+	case dt == "float" || dt == "double" || dt == "real" || dt == "double precision":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a number", s)
+		}
+		return f, nil
+	case dt == "bool" || dt == "boolean":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a boolean", s)
+		}
+		return b, nil
+	case dt == "date" || dt == "datetime" || strings.HasPrefix(dt, "timestamp"):
+		t, err := tryStrToDate(l.location, s)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a date", s)
+		}
+		return t, nil
+	}
+	// decimals stay strings to keep their precision
+	return s, nil
+}
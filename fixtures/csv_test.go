@@ -0,0 +1,62 @@
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoader_coerce(t *testing.T) {
+	l := &Loader{}
+	tinyint := &column{dataType: "tinyint"}
+	for field, want := range map[string]interface{}{"1": int64(1), "true": int64(1), "FALSE": int64(0), "-3": int64(-3)} {
+		v, err := l.coerce(tinyint, field)
+		assert.NoError(t, err, field)
+		assert.Equal(t, want, v, field)
+	}
+
+	_, err := l.coerce(&column{dataType: "int"}, "true")
+	assert.EqualError(t, err, `"true" is not an integer`)
+
+	v, err := l.coerce(&column{dataType: "bigint", unsigned: true}, "18446744073709551615")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(18446744073709551615), v)
+
+	v, err = l.coerce(&column{dataType: "boolean"}, "t")
+	assert.NoError(t, err)
+	assert.Equal(t, true, v)
+
+	v, err = l.coerce(&column{dataType: "decimal"}, "1.10")
+	assert.NoError(t, err)
+	assert.Equal(t, "1.10", v)
+}
+
+func TestLoader_LoadSQLite_csvQuote(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec("CREATE TABLE regions (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL)")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFixture(t, dir, "regions.csv", "id,'name'\n1,'Europe, West'\n2,'it''s \"quoted\"'\n3,a\"b\n")
+	f, err := New(Database(db), Dialect("sqlite3"), Directory(dir), CSV(CSVOptions{Quote: '\''}))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+
+	var names []string
+	rows, err := db.Query("SELECT name FROM regions ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		require.NoError(t, rows.Scan(&name))
+		names = append(names, name)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"Europe, West", `it's "quoted"`, `a"b`}, names)
+
+	_, err = New(Database(db), Dialect("sqlite3"), CSV(CSVOptions{Quote: ';', Delimiter: ';'}))
+	assert.EqualError(t, err, `testfixtures: invalid CSV delimiter ';'`)
+	_, err = New(Database(db), Dialect("sqlite3"), CSV(CSVOptions{Quote: '«'}))
+	assert.EqualError(t, err, `testfixtures: invalid CSV quote '«'`)
+}
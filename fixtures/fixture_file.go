@@ -1,17 +1,188 @@
 package fixtures
 
 import (
+	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/goapt/dbunit/internal/yamljson"
 )
 
 type fixtureFile struct {
-	path      string
-	fileName  string
-	content   []byte
-	insertSQL insertSQL
+	path     string
+	fileName string
+	content  []byte
+	strategy InsertStrategy
+	// tableNames are the tables the file has records for
+	tableNames []string
 }
 
 func (f *fixtureFile) fileNameWithoutExtension() string {
 	return strings.Replace(f.fileName, filepath.Ext(f.fileName), "", 1)
 }
+
+// Reserved keys of the header form of a fixture file
+const (
+	tableKey   = "_table"
+	recordsKey = "_records"
+)
+
+// fixtureTable holds the records of one table of a fixture file
+type fixtureTable struct {
+	name    string
+	records []map[string]interface{}
+}
+
+// tables unmarshals the file, which is either
+//
+//   - a list of records of the table named after the file,
+//   - a map of table names to lists of records, loaded in the order of the file,
+//   - a map with the _table header naming the table of the _records list.
+//
+// JSON files have the same forms, with arrays of objects.
+func (f *fixtureFile) tables() ([]fixtureTable, error) {
+	doc, keys, err := f.decode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch doc := doc.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		records, err := f.records(f.fileNameWithoutExtension(), doc)
+		if err != nil {
+			return nil, err
+		}
+		return []fixtureTable{{name: f.fileNameWithoutExtension(), records: records}}, nil
+	case map[interface{}]interface{}:
+		if _, ok := doc[tableKey]; ok {
+			return f.headerTable(doc)
+		}
+
+		tables := make([]fixtureTable, 0, len(keys))
+		for _, key := range keys {
+			name := fmt.Sprint(key)
+			items, ok := doc[key].([]interface{})
+			if !ok && doc[key] != nil {
+				return nil, fmt.Errorf("testfixtures: table %s in file %s is not a list of records", name, f.fileName)
+			}
+			records, err := f.records(name, items)
+			if err != nil {
+				return nil, err
+			}
+			tables = append(tables, fixtureTable{name: name, records: records})
+		}
+		return tables, nil
+	}
+	return nil, fmt.Errorf("testfixtures: file %s is neither a list of records nor a map of tables", f.fileName)
+}
+
+// decode unmarshals the file, JSON files are decoded to the values YAML
+// gives. keys are the top level keys in the order of the file.
+func (f *fixtureFile) decode() (doc interface{}, keys []interface{}, err error) {
+	if f.isJSON() {
+		if doc, keys, err = yamljson.Decode(f.content); err != nil {
+			return nil, nil, fmt.Errorf("testfixtures: could not unmarshal JSON file %s: %w", f.fileName, err)
+		}
+		return doc, keys, nil
+	}
+
+	if err = yaml.Unmarshal(f.content, &doc); err != nil {
+		return nil, nil, fmt.Errorf("testfixtures: could not unmarshal YAML file %s: %w", f.fileName, err)
+	}
+	if _, ok := doc.(map[interface{}]interface{}); ok {
+		// the values come from doc, the MapSlice only gives the order of the keys
+		var order yaml.MapSlice
+		if err = yaml.Unmarshal(f.content, &order); err != nil {
+			return nil, nil, fmt.Errorf("testfixtures: could not unmarshal YAML file %s: %w", f.fileName, err)
+		}
+		for _, item := range order {
+			keys = append(keys, item.Key)
+		}
+	}
+	return doc, keys, nil
+}
+
+func (f *fixtureFile) isJSON() bool {
+	return strings.EqualFold(filepath.Ext(f.fileName), ".json")
+}
+
+// headerTable reads the header form of a fixture file
+func (f *fixtureFile) headerTable(doc map[interface{}]interface{}) ([]fixtureTable, error) {
+	name, ok := doc[tableKey].(string)
+	if !ok || name == "" {
+		return nil, fmt.Errorf("testfixtures: %s of file %s must be a table name", tableKey, f.fileName)
+	}
+	for k := range doc {
+		if k != tableKey && k != recordsKey {
+			return nil, fmt.Errorf("testfixtures: unexpected key %v in file %s, the records go under %s", k, f.fileName, recordsKey)
+		}
+	}
+	items, ok := doc[recordsKey].([]interface{})
+	if !ok && doc[recordsKey] != nil {
+		return nil, fmt.Errorf("testfixtures: %s of file %s is not a list of records", recordsKey, f.fileName)
+	}
+	records, err := f.records(name, items)
+	if err != nil {
+		return nil, err
+	}
+	return []fixtureTable{{name: name, records: records}}, nil
+}
+
+// records converts the records of table, each must be a non empty map of
+// column names to values
+func (f *fixtureFile) records(table string, items []interface{}) ([]map[string]interface{}, error) {
+	records := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		m, ok := item.(map[interface{}]interface{})
+		if !ok || len(m) == 0 {
+			return nil, fmt.Errorf("testfixtures: record %d of table %s in file %s is not a map of columns to values", i, table, f.fileName)
+		}
+		record := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			record[fmt.Sprint(k)] = v
+		}
+		records[i] = record
+	}
+	return records, nil
+}
+
+// recordGroup is a run of consecutive records having the same columns
+type recordGroup struct {
+	// first is the index in the table of the file of the first record of the group
+	first   int
+	columns []string
+	records []map[string]interface{}
+	// labels are the labels of the records, nil when the table has none
+	labels []string
+}
+
+// groupRecords splits the records into runs sharing the same columns. Each
+// run is inserted by its own statement so the columns a record omits get
+// their DEFAULT value, and the records keep the order of the file.
+func groupRecords(records []map[string]interface{}) []recordGroup {
+	var (
+		groups []recordGroup
+		last   string
+	)
+	for i, record := range records {
+		columns := make([]string, 0, len(record))
+		for k := range record {
+			columns = append(columns, k)
+		}
+		sort.Strings(columns)
+
+		key := strings.Join(columns, "\x00")
+		if len(groups) == 0 || key != last {
+			groups = append(groups, recordGroup{first: i, columns: columns})
+			last = key
+		}
+		g := &groups[len(groups)-1]
+		g.records = append(g.records, record)
+	}
+	return groups
+}
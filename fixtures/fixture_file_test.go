@@ -12,3 +12,82 @@ func Test_fixtureFile_fileNameWithoutExtension(t *testing.T) {
 	}
 	assert.Equal(t, "orders", f.fileNameWithoutExtension())
 }
+
+func Test_fixtureFile_tables(t *testing.T) {
+	f := &fixtureFile{fileName: "users.yml", content: []byte("- id: 1\n  name: a\n- id: 2\n")}
+	tables, err := f.tables()
+	assert.NoError(t, err)
+	assert.Equal(t, []fixtureTable{{name: "users", records: []map[string]interface{}{{"id": 1, "name": "a"}, {"id": 2}}}}, tables)
+
+	f.content = []byte("- id: 1\n- 2\n")
+	_, err = f.tables()
+	assert.EqualError(t, err, "testfixtures: record 1 of table users in file users.yml is not a map of columns to values")
+
+	f.content = []byte("- id: 1\n- {}\n")
+	_, err = f.tables()
+	assert.EqualError(t, err, "testfixtures: record 1 of table users in file users.yml is not a map of columns to values")
+
+	f.content = []byte("")
+	tables, err = f.tables()
+	assert.NoError(t, err)
+	assert.Empty(t, tables)
+}
+
+func Test_fixtureFile_tables_multiple(t *testing.T) {
+	f := &fixtureFile{fileName: "shop.yml", content: []byte("users:\n  - id: 1\norders:\n  - id: 2\n    user_id: 1\n  - id: 3\n    user_id: 1\nitems:\n")}
+	tables, err := f.tables()
+	assert.NoError(t, err)
+	if assert.Len(t, tables, 3) {
+		assert.Equal(t, "users", tables[0].name)
+		assert.Len(t, tables[0].records, 1)
+		assert.Equal(t, "orders", tables[1].name)
+		assert.Len(t, tables[1].records, 2)
+		assert.Equal(t, "items", tables[2].name)
+		assert.Empty(t, tables[2].records)
+	}
+
+	f.content = []byte("users: 1\n")
+	_, err = f.tables()
+	assert.EqualError(t, err, "testfixtures: table users in file shop.yml is not a list of records")
+
+	f.content = []byte("users:\n  - 1\n")
+	_, err = f.tables()
+	assert.EqualError(t, err, "testfixtures: record 0 of table users in file shop.yml is not a map of columns to values")
+}
+
+func Test_fixtureFile_tables_header(t *testing.T) {
+	f := &fixtureFile{fileName: "admins.yml", content: []byte("_table: users\n_records:\n  - id: 1\n    role: admin\n")}
+	tables, err := f.tables()
+	assert.NoError(t, err)
+	assert.Equal(t, []fixtureTable{{name: "users", records: []map[string]interface{}{{"id": 1, "role": "admin"}}}}, tables)
+
+	f.content = []byte("_table: 1\n_records: []\n")
+	_, err = f.tables()
+	assert.EqualError(t, err, "testfixtures: _table of file admins.yml must be a table name")
+
+	f.content = []byte("_table: users\nrecords: []\n")
+	_, err = f.tables()
+	assert.EqualError(t, err, "testfixtures: unexpected key records in file admins.yml, the records go under _records")
+
+	f.content = []byte("_table: users\n_records: 1\n")
+	_, err = f.tables()
+	assert.EqualError(t, err, "testfixtures: _records of file admins.yml is not a list of records")
+}
+
+func Test_groupRecords(t *testing.T) {
+	groups := groupRecords([]map[string]interface{}{
+		{"id": 1, "name": "a"},
+		{"name": "b", "id": 2},
+		{"id": 3},
+		{"id": 4, "name": "d", "role": "admin"},
+	})
+	if assert.Len(t, groups, 3) {
+		assert.Equal(t, 0, groups[0].first)
+		assert.Equal(t, []string{"id", "name"}, groups[0].columns)
+		assert.Len(t, groups[0].records, 2)
+		assert.Equal(t, 2, groups[1].first)
+		assert.Equal(t, []string{"id"}, groups[1].columns)
+		assert.Equal(t, 3, groups[2].first)
+		assert.Equal(t, []string{"id", "name", "role"}, groups[2].columns)
+	}
+}
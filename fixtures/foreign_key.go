@@ -0,0 +1,238 @@
+package fixtures
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// foreignKey is a constraint of table referencing refTable
+type foreignKey struct {
+	name       string
+	table      string
+	columns    []string
+	refTable   string
+	refColumns []string
+}
+
+// scanForeignKeys reads rows of constraint, table, column, referenced table
+// and referenced column, ordered by constraint and column position
+func scanForeignKeys(rows *sql.Rows) ([]foreignKey, error) {
+	defer rows.Close()
+
+	var keys []foreignKey
+	for rows.Next() {
+		var name, table, column, refTable, refColumn string
+		if err := rows.Scan(&name, &table, &column, &refTable, &refColumn); err != nil {
+			return nil, err
+		}
+		if n := len(keys); n == 0 || keys[n-1].name != name || keys[n-1].table != table {
+			keys = append(keys, foreignKey{name: name, table: table, refTable: refTable})
+		}
+		k := &keys[len(keys)-1]
+		k.columns = append(k.columns, column)
+		k.refColumns = append(k.refColumns, refColumn)
+	}
+	return keys, rows.Err()
+}
+
+// CheckForeignKeys loads the fixtures with the foreign key checks enabled
+// instead of disabling them. The files are loaded parent tables first, as
+// read from the constraints of the database, and Load fails before
+// cleaning or inserting anything with a ForeignKeyError listing every
+// record referencing a row that neither the fixtures nor the database
+// have, the rows of the tables emptied by Clean don't count. These tables
+// are then cleaned in a transaction of their own. SQLite
+// checks the foreign keys only when enabled on the connection, with
+// _foreign_keys=1 in the DSN of go-sqlite3.
+func CheckForeignKeys() func(*Loader) error {
+	return func(l *Loader) error {
+		l.checkForeignKeys = true
+		return nil
+	}
+}
+
+// DanglingReference is a fixture record whose foreign key references no row
+type DanglingReference struct {
+	File   string
+	Table  string
+	Record int
+	// Columns are the columns of the foreign key, Values their values in the record
+	Columns []string
+	Values  []interface{}
+	// RefTable and RefColumns are the missing parent key
+	RefTable   string
+	RefColumns []string
+}
+
+func (d DanglingReference) String() string {
+	values := make([]string, len(d.Values))
+	for i, v := range d.Values {
+		values[i] = fmt.Sprint(v)
+	}
+	return fmt.Sprintf("file: %s, table: %s, record: %d, (%s) = (%s) references no row of %s (%s)",
+		d.File, d.Table, d.Record, strings.Join(d.Columns, ", "), strings.Join(values, ", "),
+		d.RefTable, strings.Join(d.RefColumns, ", "))
+}
+
+// ForeignKeyError is returned by Load with the CheckForeignKeys option when
+// fixture records reference missing rows
+type ForeignKeyError struct {
+	Dangling []DanglingReference
+}
+
+func (e *ForeignKeyError) Error() string {
+	lines := make([]string, len(e.Dangling))
+	for i, d := range e.Dangling {
+		lines[i] = "\n\t" + d.String()
+	}
+	return fmt.Sprintf("testfixtures: %d dangling foreign keys:%s", len(e.Dangling), strings.Join(lines, ""))
+}
+
+// addForeignKeyDeps makes the sections of every table depend on the sections
+// of the tables it references
+func (l *Loader) addForeignKeyDeps(sections []*fixtureSection) {
+	for _, s := range sections {
+		for _, fk := range l.foreignKeys {
+			if fk.table != s.table || fk.refTable == s.table {
+				continue
+			}
+			for _, parent := range sections {
+				if parent.table == fk.refTable && !containsSection(s.deps, parent) {
+					s.deps = append(s.deps, parent)
+				}
+			}
+		}
+	}
+}
+
+// danglingReferences lists the foreign keys of the fixture records whose
+// parent key is neither in the fixtures nor in the database. Keys holding a
+// $ref value are resolved from a labelled record so they are not checked,
+// the records of CSV files are left to the checks of the database. The rows
+// of the cleaned tables are ignored, they are deleted before the insert.
+func (l *Loader) danglingReferences(tx *sql.Tx, cleaned []string) ([]DanglingReference, error) {
+	var (
+		dangling []DanglingReference
+		parents  = make(map[string]map[string]bool)
+	)
+	for _, s := range l.sections {
+		for _, fk := range l.foreignKeys {
+			if fk.table != s.table {
+				continue
+			}
+			keyName := fk.refTable + "\x00" + strings.Join(fk.refColumns, "\x00")
+			if parents[keyName] == nil {
+				keys, err := l.fixtureKeys(fk.refTable, fk.refColumns)
+				if err != nil {
+					return nil, err
+				}
+				parents[keyName] = keys
+			}
+
+			for _, insert := range s.inserts {
+				for i, record := range insert.records {
+					values, ok := keyValues(record, fk.columns)
+					if !ok || parents[keyName][keyString(values)] {
+						continue
+					}
+					if !containsString(cleaned, fk.refTable) {
+						found, err := l.rowExists(tx, fk.refTable, fk.refColumns, values)
+						if err != nil {
+							return nil, err
+						}
+						if found {
+							continue
+						}
+					}
+					dangling = append(dangling, DanglingReference{
+						File:       s.file.fileName,
+						Table:      s.table,
+						Record:     insert.first + i,
+						Columns:    fk.columns,
+						Values:     values,
+						RefTable:   fk.refTable,
+						RefColumns: fk.refColumns,
+					})
+				}
+			}
+		}
+	}
+	return dangling, nil
+}
+
+// fixtureKeys returns the values of columns in the fixture records of table
+func (l *Loader) fixtureKeys(table string, columns []string) (map[string]bool, error) {
+	keys := make(map[string]bool)
+	for _, s := range l.sections {
+		if s.table != table {
+			continue
+		}
+		if s.csv != nil {
+			err := l.readCSV(s, func(_ int, records []map[string]interface{}) error {
+				for _, record := range records {
+					if values, ok := keyValues(record, columns); ok {
+						keys[keyString(values)] = true
+					}
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		for _, insert := range s.inserts {
+			for _, record := range insert.records {
+				if values, ok := keyValues(record, columns); ok {
+					keys[keyString(values)] = true
+				}
+			}
+		}
+	}
+	return keys, nil
+}
+
+// keyValues returns the values of columns in record, false when one of them
+// is missing, NULL or a reference
+func keyValues(record map[string]interface{}, columns []string) ([]interface{}, bool) {
+	values := make([]interface{}, len(columns))
+	for i, c := range columns {
+		v, ok := record[c]
+		if !ok || v == nil {
+			return nil, false
+		}
+		if _, ok := v.(reference); ok {
+			return nil, false
+		}
+		values[i] = v
+	}
+	return values, true
+}
+
+func keyString(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// rowExists tells whether table has a row with values in columns
+func (l *Loader) rowExists(tx *sql.Tx, table string, columns []string, values []interface{}) (bool, error) {
+	conditions := make([]string, len(columns))
+	for i, c := range columns {
+		conditions[i] = fmt.Sprintf("%s = %s", l.helper.quoteKeyword(c), l.bindVar(i+1))
+	}
+	query := fmt.Sprintf("SELECT 1 FROM %s WHERE %s LIMIT 1", l.helper.quoteKeyword(table), strings.Join(conditions, " AND "))
+
+	var one int
+	err := tx.QueryRow(query, values...).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("testfixtures: could not check the foreign keys of %s: %w", table, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,94 @@
+package fixtures
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+const (
+	paramTypeQuestion = iota + 1
+	paramTypeDollar
+)
+
+type loadFunction func(tx *sql.Tx) error
+
+// errNoRecordInserted is returned when the key of a labelled record can't be
+// read because the insert was ignored
+var errNoRecordInserted = errors.New("testfixtures: the record was not inserted, no key was generated")
+
+type helper interface {
+	init(*sql.DB) error
+	paramType() int
+	// maxPlaceholders is the most bind variables a statement may have
+	maxPlaceholders() int
+	databaseName(*sql.DB) (string, error)
+	tableNames(*sql.DB) ([]string, error)
+	quoteKeyword(string) string
+	// defaultInsertStrategy is used when the Insert option is not given
+	defaultInsertStrategy() InsertStrategy
+	// insertClauses returns the text before and after the VALUES rows of a
+	// statement inserting columns into table with strategy
+	insertClauses(strategy InsertStrategy, table string, columns []string) (head, tail string, err error)
+	// cleanTables empties tables with TRUNCATE, or DELETE when truncate is false
+	cleanTables(tx *sql.Tx, tables []string, truncate bool) error
+	disableReferentialIntegrity(*sql.DB, loadFunction) error
+	afterLoad(*sql.Tx) error
+	// primaryKey lists the primary key columns of table in order
+	primaryKey(db *sql.DB, table string) ([]string, error)
+	// insertReturning runs the insert of a single record and returns the
+	// value generated for column
+	insertReturning(tx *sql.Tx, query string, params []interface{}, column string) (interface{}, error)
+	// foreignKeys lists the foreign key constraints of the database
+	foreignKeys(db *sql.DB) ([]foreignKey, error)
+	// columns describes the columns of table, none when it doesn't exist
+	columns(db *sql.DB, table string) ([]column, error)
+	// resetSequences sets the next generated id of tables to max(id)+1, or
+	// to the start of the table when larger, it runs after the load
+	// transaction is committed
+	resetSequences(db *sql.DB, tables []string, starts map[string]int64) error
+}
+
+func helperForDialect(dialect string) (helper, error) {
+	switch dialect {
+	case "mysql", "mariadb":
+		return &mySQL{}, nil
+	case "postgres", "postgresql", "pgx":
+		return &postgreSQL{}, nil
+	case "sqlite", "sqlite3":
+		return &sqlite{}, nil
+	}
+	return nil, fmt.Errorf(`testfixtures: unrecognized dialect "%s"`, dialect)
+}
+
+// lastInsertID runs the insert of a single record and returns the id
+// generated by AUTO_INCREMENT or the SQLite rowid
+func lastInsertID(tx *sql.Tx, query string, params []interface{}) (interface{}, error) {
+	res, err := tx.Exec(query, params...)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, errNoRecordInserted
+	}
+	return res.LastInsertId()
+}
+
+// queryStrings returns the first column of the rows of query
+func queryStrings(db *sql.DB, query string, args ...interface{}) ([]string, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var values []string
+	for rows.Next() {
+		var v string
+		if err = rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
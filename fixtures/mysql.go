@@ -3,10 +3,9 @@ package fixtures
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 )
 
-type loadFunction func(tx *sql.Tx) error
-
 type mySQL struct {
 	tables []string
 }
@@ -21,6 +20,15 @@ func (h *mySQL) init(db *sql.DB) error {
 	return nil
 }
 
+func (*mySQL) paramType() int {
+	return paramTypeQuestion
+}
+
+// maxPlaceholders is the limit of prepared statements, counted on 16 bits
+func (*mySQL) maxPlaceholders() int {
+	return 65535
+}
+
 func (*mySQL) quoteKeyword(str string) string {
 	return fmt.Sprintf("`%s`", str)
 }
@@ -64,6 +72,108 @@ func (h *mySQL) tableNames(q *sql.DB) ([]string, error) {
 
 }
 
+func (*mySQL) defaultInsertStrategy() InsertStrategy {
+	return StrategyReplace
+}
+
+func (h *mySQL) insertClauses(strategy InsertStrategy, table string, columns []string) (string, string, error) {
+	switch strategy {
+	case StrategyInsert:
+		return insertHead(h, "INSERT INTO", table, columns), "", nil
+	case StrategyInsertIgnore:
+		return insertHead(h, "INSERT IGNORE INTO", table, columns), "", nil
+	case StrategyUpsert:
+		quoted := quoteKeywords(h, columns)
+		sets := make([]string, len(quoted))
+		for i, c := range quoted {
+			sets[i] = fmt.Sprintf("%s = VALUES(%s)", c, c)
+		}
+		return insertHead(h, "INSERT INTO", table, columns), " ON DUPLICATE KEY UPDATE " + strings.Join(sets, ", "), nil
+	case StrategyReplace:
+		return insertHead(h, "REPLACE INTO", table, columns), "", nil
+	}
+	return "", "", fmt.Errorf("testfixtures: unknown insert strategy %d", strategy)
+}
+
+func (h *mySQL) cleanTables(tx *sql.Tx, tables []string, truncate bool) error {
+	for _, table := range tables {
+		query := "DELETE FROM " + h.quoteKeyword(table)
+		if truncate {
+			query = "TRUNCATE TABLE " + h.quoteKeyword(table)
+		}
+		if _, err := tx.Exec(query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*mySQL) primaryKey(db *sql.DB, table string) ([]string, error) {
+	return queryStrings(db, `
+		SELECT column_name
+		FROM information_schema.key_column_usage
+		WHERE table_schema = DATABASE()
+		  AND table_name = ?
+		  AND constraint_name = 'PRIMARY'
+		ORDER BY ordinal_position;
+	`, table)
+}
+
+func (*mySQL) insertReturning(tx *sql.Tx, query string, params []interface{}, _ string) (interface{}, error) {
+	return lastInsertID(tx, query, params)
+}
+
+func (*mySQL) foreignKeys(db *sql.DB) ([]foreignKey, error) {
+	rows, err := db.Query(`
+		SELECT k.constraint_name, k.table_name, k.column_name, k.referenced_table_name, k.referenced_column_name
+		FROM information_schema.referential_constraints r
+		JOIN information_schema.key_column_usage k
+		  ON k.constraint_schema = r.constraint_schema
+		 AND k.constraint_name = r.constraint_name
+		 AND k.table_name = r.table_name
+		WHERE r.constraint_schema = DATABASE()
+		ORDER BY k.table_name, k.constraint_name, k.ordinal_position;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanForeignKeys(rows)
+}
+
+func (*mySQL) columns(db *sql.DB, table string) ([]column, error) {
+	rows, err := db.Query(`
+		SELECT column_name, data_type, column_type, is_nullable = 'YES',
+		       column_default IS NOT NULL OR extra LIKE '%auto_increment%' OR extra LIKE '%GENERATED%',
+		       COALESCE(character_maximum_length, 0)
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		  AND table_name = ?
+		ORDER BY ordinal_position;
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []column
+	for rows.Next() {
+		var (
+			c          column
+			columnType string
+		)
+		if err = rows.Scan(&c.name, &c.dataType, &columnType, &c.nullable, &c.hasDefault, &c.length); err != nil {
+			return nil, err
+		}
+		c.dataType = strings.ToLower(c.dataType)
+		c.unsigned = strings.Contains(strings.ToLower(columnType), "unsigned")
+		if c.dataType == "enum" {
+			c.enum = parseEnum(columnType)
+		}
+		columns = append(columns, c)
+	}
+	return columns, rows.Err()
+}
+
 func (h *mySQL) disableReferentialIntegrity(db *sql.DB, loadFn loadFunction) (err error) {
 	tx, err := db.Begin()
 	if err != nil {
@@ -86,3 +196,48 @@ func (h *mySQL) disableReferentialIntegrity(db *sql.DB, loadFn loadFunction) (er
 
 	return tx.Commit()
 }
+
+func (*mySQL) afterLoad(*sql.Tx) error {
+	return nil
+}
+
+// resetSequences relies on InnoDB never moving the counter to or below the
+// largest id in use, ALTER TABLE commits implicitly so it runs after the load
+func (h *mySQL) resetSequences(db *sql.DB, tables []string, starts map[string]int64) error {
+	rows, err := db.Query(`
+		SELECT table_name
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		  AND extra LIKE '%auto_increment%';
+	`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	autoIncrement := make(map[string]bool)
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return err
+		}
+		autoIncrement[table] = true
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if !autoIncrement[table] {
+			continue
+		}
+		start := starts[table]
+		if start < 1 {
+			start = 1
+		}
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s AUTO_INCREMENT = %d", h.quoteKeyword(table), start)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
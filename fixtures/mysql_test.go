@@ -16,12 +16,8 @@ var (
 )
 
 func init() {
-	if os.Getenv("DRONE") == "true" {
-		testDSN = "root:123456@tcp(database:3306)/"
-	}
-
-	if os.Getenv("CI") == "true" {
-		testDSN = "root:root@tcp(127.0.0.1:3306)/"
+	if dsn := os.Getenv("DBUNIT_DSN"); dsn != "" {
+		testDSN = dsn
 	}
 
 	mysqlDSN = fmt.Sprintf("%smysql", testDSN)
@@ -0,0 +1,371 @@
+package fixtures
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+type postgreSQL struct {
+	tables    []string
+	sequences []pgSequence
+	// primaryKeys are the primary key columns of each table, the conflict
+	// target of upserts
+	primaryKeys map[string][]string
+
+	// superuser can switch session_replication_role to skip foreign key
+	// triggers, other users fall back to deferring the constraints, which
+	// only works for the DEFERRABLE ones
+	superuser bool
+}
+
+type pgSequence struct {
+	name   string
+	table  string
+	column string
+}
+
+func (h *postgreSQL) init(db *sql.DB) error {
+	var err error
+	h.tables, err = h.tableNames(db)
+	if err != nil {
+		return err
+	}
+
+	h.sequences, err = h.sequenceColumns(db)
+	if err != nil {
+		return err
+	}
+
+	h.primaryKeys, err = h.primaryKeyColumns(db)
+	if err != nil {
+		return err
+	}
+
+	err = db.QueryRow("SELECT rolsuper FROM pg_roles WHERE rolname = current_user").Scan(&h.superuser)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (*postgreSQL) paramType() int {
+	return paramTypeDollar
+}
+
+// maxPlaceholders is the limit of the Bind message, counted on 16 bits
+func (*postgreSQL) maxPlaceholders() int {
+	return 65535
+}
+
+func (*postgreSQL) quoteKeyword(str string) string {
+	parts := strings.Split(str, ".")
+	for i, p := range parts {
+		parts[i] = fmt.Sprintf(`"%s"`, p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func (*postgreSQL) databaseName(q *sql.DB) (string, error) {
+	var dbName string
+	err := q.QueryRow("SELECT current_database()").Scan(&dbName)
+	return dbName, err
+}
+
+func (*postgreSQL) tableNames(q *sql.DB) ([]string, error) {
+	query := `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = current_schema()
+		  AND table_type = 'BASE TABLE';
+	`
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// sequenceColumns lists the sequences owned by serial and identity columns
+func (*postgreSQL) sequenceColumns(q *sql.DB) ([]pgSequence, error) {
+	query := `
+		SELECT s.relname, t.relname, a.attname
+		FROM pg_class s
+		JOIN pg_depend d ON d.objid = s.oid AND d.classid = 'pg_class'::regclass AND d.refclassid = 'pg_class'::regclass
+		JOIN pg_class t ON t.oid = d.refobjid
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = d.refobjsubid
+		JOIN pg_namespace n ON n.oid = s.relnamespace
+		WHERE s.relkind = 'S'
+		  AND n.nspname = current_schema();
+	`
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sequences []pgSequence
+	for rows.Next() {
+		var seq pgSequence
+		if err = rows.Scan(&seq.name, &seq.table, &seq.column); err != nil {
+			return nil, err
+		}
+		sequences = append(sequences, seq)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sequences, nil
+}
+
+// primaryKeyColumns lists the primary key columns of the tables in order
+func (*postgreSQL) primaryKeyColumns(q *sql.DB) (map[string][]string, error) {
+	query := `
+		SELECT c.relname, a.attname
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = ANY(i.indkey)
+		WHERE i.indisprimary
+		  AND n.nspname = current_schema()
+		ORDER BY c.relname, array_position(i.indkey::int2[], a.attnum);
+	`
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[string][]string)
+	for rows.Next() {
+		var table, column string
+		if err = rows.Scan(&table, &column); err != nil {
+			return nil, err
+		}
+		keys[table] = append(keys[table], column)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (*postgreSQL) defaultInsertStrategy() InsertStrategy {
+	return StrategyInsert
+}
+
+func (h *postgreSQL) insertClauses(strategy InsertStrategy, table string, columns []string) (string, string, error) {
+	head := insertHead(h, "INSERT INTO", table, columns)
+	switch strategy {
+	case StrategyInsert:
+		return head, "", nil
+	case StrategyInsertIgnore:
+		return head, " ON CONFLICT DO NOTHING", nil
+	case StrategyUpsert:
+		keys := h.primaryKeys[table]
+		if len(keys) == 0 {
+			return "", "", fmt.Errorf("testfixtures: table %s has no primary key to upsert on", table)
+		}
+		isKey := make(map[string]bool, len(keys))
+		for _, k := range keys {
+			isKey[k] = true
+		}
+		var sets []string
+		for _, c := range columns {
+			if !isKey[c] {
+				sets = append(sets, fmt.Sprintf("%s = EXCLUDED.%s", h.quoteKeyword(c), h.quoteKeyword(c)))
+			}
+		}
+		tail := fmt.Sprintf(" ON CONFLICT (%s) DO NOTHING", strings.Join(quoteKeywords(h, keys), ", "))
+		if len(sets) > 0 {
+			tail = fmt.Sprintf(" ON CONFLICT (%s) DO UPDATE SET %s", strings.Join(quoteKeywords(h, keys), ", "), strings.Join(sets, ", "))
+		}
+		return head, tail, nil
+	case StrategyReplace:
+		return "", "", fmt.Errorf("testfixtures: insert strategy %s is not supported by PostgreSQL", strategy)
+	}
+	return "", "", fmt.Errorf("testfixtures: unknown insert strategy %d", strategy)
+}
+
+// cleanTables truncates all the tables in one statement, which PostgreSQL
+// requires when they reference each other
+func (h *postgreSQL) cleanTables(tx *sql.Tx, tables []string, truncate bool) error {
+	if truncate {
+		_, err := tx.Exec("TRUNCATE TABLE " + strings.Join(quoteKeywords(h, tables), ", "))
+		return err
+	}
+	for _, table := range tables {
+		if _, err := tx.Exec("DELETE FROM " + h.quoteKeyword(table)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (h *postgreSQL) primaryKey(_ *sql.DB, table string) ([]string, error) {
+	return h.primaryKeys[table], nil
+}
+
+func (h *postgreSQL) insertReturning(tx *sql.Tx, query string, params []interface{}, column string) (interface{}, error) {
+	var id interface{}
+	err := tx.QueryRow(query+" RETURNING "+h.quoteKeyword(column), params...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, errNoRecordInserted
+	}
+	return id, err
+}
+
+func (*postgreSQL) foreignKeys(db *sql.DB) ([]foreignKey, error) {
+	rows, err := db.Query(`
+		SELECT k.constraint_name, k.table_name, k.column_name, p.table_name, p.column_name
+		FROM information_schema.referential_constraints r
+		JOIN information_schema.key_column_usage k
+		  ON k.constraint_schema = r.constraint_schema
+		 AND k.constraint_name = r.constraint_name
+		JOIN information_schema.key_column_usage p
+		  ON p.constraint_schema = r.unique_constraint_schema
+		 AND p.constraint_name = r.unique_constraint_name
+		 AND p.ordinal_position = k.position_in_unique_constraint
+		WHERE r.constraint_schema = current_schema()
+		ORDER BY k.table_name, k.constraint_name, k.ordinal_position;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	return scanForeignKeys(rows)
+}
+
+// columns reports the values of enum types, which are USER-DEFINED in
+// information_schema, as enum columns
+func (*postgreSQL) columns(db *sql.DB, table string) ([]column, error) {
+	rows, err := db.Query(`
+		SELECT column_name, data_type, udt_name, is_nullable = 'YES',
+		       column_default IS NOT NULL OR is_identity = 'YES' OR is_generated <> 'NEVER',
+		       COALESCE(character_maximum_length, 0)
+		FROM information_schema.columns
+		WHERE table_schema = current_schema()
+		  AND table_name = $1
+		ORDER BY ordinal_position;
+	`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		columns []column
+		udts    []string
+	)
+	for rows.Next() {
+		var (
+			c   column
+			udt string
+		)
+		if err = rows.Scan(&c.name, &c.dataType, &udt, &c.nullable, &c.hasDefault, &c.length); err != nil {
+			return nil, err
+		}
+		c.dataType = strings.ToLower(c.dataType)
+		columns = append(columns, c)
+		udts = append(udts, udt)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range columns {
+		if columns[i].dataType != "user-defined" {
+			continue
+		}
+		labels, err := queryStrings(db, `
+			SELECT e.enumlabel
+			FROM pg_type t
+			JOIN pg_enum e ON e.enumtypid = t.oid
+			WHERE t.typname = $1
+			ORDER BY e.enumsortorder;
+		`, udts[i])
+		if err != nil {
+			return nil, err
+		}
+		if len(labels) > 0 {
+			columns[i].dataType, columns[i].enum = "enum", labels
+		}
+	}
+	return columns, nil
+}
+
+// disableReferentialIntegrity skips the foreign key triggers as a superuser.
+// SET CONSTRAINTS ALL DEFERRED only defers the constraints declared
+// DEFERRABLE, the others are still checked on every statement, so the
+// fixtures of other users are loaded parent tables first.
+func (h *postgreSQL) disableReferentialIntegrity(db *sql.DB, loadFn loadFunction) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "SET CONSTRAINTS ALL DEFERRED"
+	if h.superuser {
+		query = "SET LOCAL session_replication_role = replica"
+	}
+	if _, err = tx.Exec(query); err != nil {
+		return err
+	}
+
+	if err = loadFn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// afterLoad moves every sequence past the ids written by the fixtures,
+// explicit values never advance a sequence in PostgreSQL
+func (h *postgreSQL) afterLoad(tx *sql.Tx) error {
+	for _, seq := range h.sequences {
+		if _, err := tx.Exec(h.setvalQuery(seq, 0)); err != nil {
+			return fmt.Errorf("testfixtures: could not reset sequence %s: %w", seq.name, err)
+		}
+	}
+	return nil
+}
+
+// resetSequences only applies the starts, afterLoad already moved every
+// sequence to max(id)+1
+func (h *postgreSQL) resetSequences(db *sql.DB, tables []string, starts map[string]int64) error {
+	for _, seq := range h.sequences {
+		start := starts[seq.table]
+		if start <= 0 || !containsString(tables, seq.table) {
+			continue
+		}
+		if _, err := db.Exec(h.setvalQuery(seq, start)); err != nil {
+			return fmt.Errorf("sequence %s: %w", seq.name, err)
+		}
+	}
+	return nil
+}
+
+// setvalQuery sets the next value of seq to max(id)+1, or to start when greater
+func (h *postgreSQL) setvalQuery(seq pgSequence, start int64) string {
+	return fmt.Sprintf(
+		"SELECT setval('%s', GREATEST(COALESCE((SELECT MAX(%s) FROM %s), 0) + 1, %d), false)",
+		h.quoteKeyword(seq.name),
+		h.quoteKeyword(seq.column),
+		h.quoteKeyword(seq.table),
+		start,
+	)
+}
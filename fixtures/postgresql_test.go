@@ -0,0 +1,32 @@
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_postgreSQL_quoteKeyword(t *testing.T) {
+	h := &postgreSQL{}
+	assert.Equal(t, `"users"`, h.quoteKeyword("users"))
+	assert.Equal(t, `"public"."users"`, h.quoteKeyword("public.users"))
+}
+
+func TestDialect(t *testing.T) {
+	l := &Loader{}
+	assert.NoError(t, Dialect("postgres")(l))
+	assert.IsType(t, &postgreSQL{}, l.helper)
+	assert.Equal(t, "$3", l.bindVar(3))
+
+	assert.NoError(t, Dialect("mysql")(l))
+	assert.Equal(t, "?", l.bindVar(3))
+
+	assert.Error(t, Dialect("oracle")(l))
+}
+
+func Test_postgreSQL_setvalQuery(t *testing.T) {
+	h := &postgreSQL{}
+	seq := pgSequence{name: "orders_id_seq", table: "orders", column: "id"}
+	assert.Equal(t, `SELECT setval('"orders_id_seq"', GREATEST(COALESCE((SELECT MAX("id") FROM "orders"), 0) + 1, 10000), false)`,
+		h.setvalQuery(seq, 10000))
+}
@@ -0,0 +1,266 @@
+package fixtures
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// labelKey names a record so that the records of other tables can reference
+// its primary key with $ref(table.label)
+const labelKey = "_label"
+
+// referenceRegexp matches $ref(table.label), the table may be qualified by
+// its schema so the label is what follows the last dot
+var referenceRegexp = regexp.MustCompile(`^\$ref\((.+)\.([^.()]+)\)$`)
+
+// reference is a column value replaced by the primary key of the record
+// labelled label in table when loading
+type reference struct {
+	table string
+	label string
+}
+
+func (r reference) String() string {
+	return fmt.Sprintf("$ref(%s.%s)", r.table, r.label)
+}
+
+// parseReference tells whether v is a reference
+func parseReference(v interface{}) (reference, bool) {
+	s, ok := v.(string)
+	if !ok || !strings.HasPrefix(s, "$ref(") {
+		return reference{}, false
+	}
+	m := referenceRegexp.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return reference{}, false
+	}
+	return reference{table: m[1], label: m[2]}, true
+}
+
+// fixtureSection holds the statements inserting the records of one table of
+// a fixture file, the sections are loaded in the order of their references
+type fixtureSection struct {
+	file    *fixtureFile
+	table   string
+	inserts []insertSQL
+	// csv is set for a CSV file, its records are read while loading
+	csv *csvSource
+	// deps are the other sections defining the labels referenced
+	deps []*fixtureSection
+}
+
+// labelled is where a label is defined
+type labelled struct {
+	section *fixtureSection
+	record  int
+}
+
+// prepareReferences removes the labels of the records of table and turns
+// their $ref values into references. It returns the label of each record.
+func prepareReferences(f *fixtureFile, table fixtureTable) ([]string, error) {
+	labels := make([]string, len(table.records))
+	for i, record := range table.records {
+		for column, v := range record {
+			if r, ok := parseReference(v); ok {
+				record[column] = r
+			}
+		}
+
+		v, ok := record[labelKey]
+		if !ok {
+			continue
+		}
+		delete(record, labelKey)
+		label, ok := v.(string)
+		if !ok || label == "" || strings.ContainsAny(label, ".()") {
+			return nil, fmt.Errorf("testfixtures: invalid %s %v of record %d of table %s in file %s", labelKey, v, i, table.name, f.fileName)
+		}
+		if len(record) == 0 {
+			return nil, fmt.Errorf("testfixtures: record %d of table %s in file %s is not a map of columns to values", i, table.name, f.fileName)
+		}
+		labels[i] = label
+	}
+	return labels, nil
+}
+
+// isolateGenerated puts every labelled record without its primary key in a
+// group of its own, the key generated for it is read back after the insert
+func isolateGenerated(group recordGroup, primaryKey string) []recordGroup {
+	if primaryKey == "" || containsString(group.columns, primaryKey) {
+		return []recordGroup{group}
+	}
+
+	var (
+		groups []recordGroup
+		start  int
+	)
+	cut := func(end int) {
+		if end > start {
+			groups = append(groups, recordGroup{
+				first:   group.first + start,
+				columns: group.columns,
+				records: group.records[start:end],
+				labels:  group.labels[start:end],
+			})
+		}
+		start = end
+	}
+	for i, label := range group.labels {
+		if label != "" {
+			cut(i)
+			cut(i + 1)
+		}
+	}
+	cut(len(group.records))
+	return groups
+}
+
+// splitSelfReferences cuts group before every record referencing a label of
+// table defined by an earlier record of the same group, the key of a label is
+// only known once the statement inserting it has run
+func splitSelfReferences(group recordGroup, table string) []recordGroup {
+	var (
+		groups []recordGroup
+		start  int
+		seen   = make(map[string]bool)
+	)
+	for i, record := range group.records {
+		for _, v := range record {
+			if r, ok := v.(reference); ok && r.table == table && seen[r.label] {
+				groups = append(groups, recordGroup{
+					first:   group.first + start,
+					columns: group.columns,
+					records: group.records[start:i],
+					labels:  group.labels[start:i],
+				})
+				start = i
+				seen = make(map[string]bool)
+				break
+			}
+		}
+		if label := group.labels[i]; label != "" {
+			seen[label] = true
+		}
+	}
+	if start == 0 {
+		return []recordGroup{group}
+	}
+	return append(groups, recordGroup{
+		first:   group.first + start,
+		columns: group.columns,
+		records: group.records[start:],
+		labels:  group.labels[start:],
+	})
+}
+
+// orderSections sorts the sections so that the labels are inserted before
+// the records referencing them, the order of the files is kept otherwise
+func orderSections(sections []*fixtureSection) ([]*fixtureSection, error) {
+	var (
+		ordered = make([]*fixtureSection, 0, len(sections))
+		done    = make(map[*fixtureSection]bool, len(sections))
+	)
+	for len(ordered) < len(sections) {
+		progress := false
+		for _, s := range sections {
+			if done[s] {
+				continue
+			}
+			ready := true
+			for _, dep := range s.deps {
+				if !done[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				done[s] = true
+				ordered = append(ordered, s)
+				progress = true
+				break
+			}
+		}
+		if !progress {
+			var cycle []string
+			for _, s := range sections {
+				if !done[s] {
+					cycle = append(cycle, fmt.Sprintf("%s (%s)", s.table, s.file.fileName))
+				}
+			}
+			return nil, fmt.Errorf("testfixtures: circular references between %s", strings.Join(cycle, ", "))
+		}
+	}
+	return ordered, nil
+}
+
+// resolve returns the params of insert with the references replaced by the
+// primary keys of the records already loaded
+func (l *Loader) resolve(file *fixtureFile, insert insertSQL) ([]interface{}, error) {
+	var params []interface{}
+	for i, v := range insert.params {
+		r, ok := v.(reference)
+		if !ok {
+			continue
+		}
+		key, ok := l.keys[r]
+		if !ok {
+			return nil, &ReferenceError{
+				Reference: r.String(),
+				File:      file.fileName,
+				Table:     insert.table,
+				Record:    insert.first + i/len(insert.columns),
+				Column:    insert.columns[i%len(insert.columns)],
+			}
+		}
+		if params == nil {
+			params = make([]interface{}, len(insert.params))
+			copy(params, insert.params)
+		}
+		params[i] = key
+	}
+	if params == nil {
+		return insert.params, nil
+	}
+	return params, nil
+}
+
+// register saves the primary keys of the labelled records of insert, id is
+// the key generated by the database for a record without one
+func (l *Loader) register(insert insertSQL, params []interface{}, id interface{}) {
+	for i, label := range insert.labels {
+		if label == "" {
+			continue
+		}
+		key := id
+		if !insert.generated {
+			key = params[i*len(insert.columns)+indexOf(insert.columns, insert.primaryKey)]
+		}
+		l.keys[reference{table: insert.table, label: label}] = key
+	}
+}
+
+// ReferenceError is returned when a $ref value names no labelled record, or
+// a record loaded after the one referencing it
+type ReferenceError struct {
+	Reference string
+	File      string
+	Table     string
+	// Record is the index of the record in the table of the file
+	Record int
+	Column string
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("testfixtures: unresolved reference %s, on file: %s, table: %s, record: %d, column: %s",
+		e.Reference, e.File, e.Table, e.Record, e.Column)
+}
+
+func indexOf(values []string, s string) int {
+	for i, v := range values {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,86 @@
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseReference(t *testing.T) {
+	r, ok := parseReference("$ref(users.alice)")
+	assert.True(t, ok)
+	assert.Equal(t, reference{table: "users", label: "alice"}, r)
+
+	r, ok = parseReference("$ref(public.users.alice)")
+	assert.True(t, ok)
+	assert.Equal(t, reference{table: "public.users", label: "alice"}, r)
+
+	for _, v := range []interface{}{"$ref(users)", "$ref(users.)", "ref(users.alice)", "$ref(users.alice", 1} {
+		_, ok = parseReference(v)
+		assert.False(t, ok, "%v", v)
+	}
+}
+
+func Test_orderSections(t *testing.T) {
+	f := &fixtureFile{fileName: "shop.yml"}
+	users := &fixtureSection{file: f, table: "users"}
+	orders := &fixtureSection{file: f, table: "orders"}
+	items := &fixtureSection{file: f, table: "items"}
+	orders.deps = []*fixtureSection{users}
+	items.deps = []*fixtureSection{orders}
+
+	ordered, err := orderSections([]*fixtureSection{items, orders, users})
+	assert.NoError(t, err)
+	assert.Equal(t, []*fixtureSection{users, orders, items}, ordered)
+
+	users.deps = []*fixtureSection{items}
+	_, err = orderSections([]*fixtureSection{items, orders, users})
+	assert.EqualError(t, err, "testfixtures: circular references between items (shop.yml), orders (shop.yml), users (shop.yml)")
+}
+
+func Test_isolateGenerated(t *testing.T) {
+	group := recordGroup{
+		first:   2,
+		columns: []string{"name"},
+		records: []map[string]interface{}{{"name": "a"}, {"name": "b"}, {"name": "c"}, {"name": "d"}},
+		labels:  []string{"", "b", "", ""},
+	}
+	groups := isolateGenerated(group, "id")
+	if assert.Len(t, groups, 3) {
+		assert.Equal(t, 2, groups[0].first)
+		assert.Len(t, groups[0].records, 1)
+		assert.Equal(t, 3, groups[1].first)
+		assert.Equal(t, []string{"b"}, groups[1].labels)
+		assert.Equal(t, 4, groups[2].first)
+		assert.Len(t, groups[2].records, 2)
+	}
+
+	assert.Len(t, isolateGenerated(group, ""), 1)
+	group.columns = []string{"id"}
+	assert.Len(t, isolateGenerated(group, "id"), 1)
+}
+
+func Test_splitSelfReferences(t *testing.T) {
+	group := recordGroup{
+		first:   1,
+		columns: []string{"id", "parent_id"},
+		records: []map[string]interface{}{
+			{"id": 1, "parent_id": nil},
+			{"id": 2, "parent_id": reference{table: "categories", label: "root"}},
+			{"id": 3, "parent_id": reference{table: "categories", label: "root"}},
+			{"id": 4, "parent_id": reference{table: "categories", label: "child"}},
+		},
+		labels: []string{"root", "child", "", ""},
+	}
+	groups := splitSelfReferences(group, "categories")
+	if assert.Len(t, groups, 3) {
+		assert.Equal(t, 1, groups[0].first)
+		assert.Equal(t, []string{"root"}, groups[0].labels)
+		assert.Equal(t, 2, groups[1].first)
+		assert.Len(t, groups[1].records, 2)
+		assert.Equal(t, 4, groups[2].first)
+		assert.Len(t, groups[2].records, 1)
+	}
+
+	assert.Len(t, splitSelfReferences(group, "tags"), 1)
+}
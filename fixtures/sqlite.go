@@ -0,0 +1,264 @@
+package fixtures
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// sqliteMemory is reported as the name of in-memory databases
+const sqliteMemory = ":memory:"
+
+type sqlite struct {
+	tables []string
+}
+
+func (h *sqlite) init(db *sql.DB) error {
+	var err error
+	h.tables, err = h.tableNames(db)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (*sqlite) paramType() int {
+	return paramTypeQuestion
+}
+
+// maxPlaceholders is SQLITE_MAX_VARIABLE_NUMBER of SQLite 3.32 and later
+func (*sqlite) maxPlaceholders() int {
+	return 32766
+}
+
+func (*sqlite) quoteKeyword(str string) string {
+	return fmt.Sprintf(`"%s"`, str)
+}
+
+func (*sqlite) databaseName(q *sql.DB) (string, error) {
+	var file string
+	err := q.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file)
+	if err != nil {
+		return "", err
+	}
+	if file == "" {
+		return sqliteMemory, nil
+	}
+	return filepath.Base(file), nil
+}
+
+func (*sqlite) tableNames(q *sql.DB) ([]string, error) {
+	query := `
+		SELECT name
+		FROM sqlite_master
+		WHERE type = 'table'
+		  AND name NOT LIKE 'sqlite_%';
+	`
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err = rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+func (*sqlite) defaultInsertStrategy() InsertStrategy {
+	return StrategyReplace
+}
+
+func (h *sqlite) insertClauses(strategy InsertStrategy, table string, columns []string) (string, string, error) {
+	switch strategy {
+	case StrategyInsert:
+		return insertHead(h, "INSERT INTO", table, columns), "", nil
+	case StrategyInsertIgnore:
+		return insertHead(h, "INSERT OR IGNORE INTO", table, columns), "", nil
+	case StrategyUpsert:
+		// SQLite 3.35 and later accept a DO UPDATE without conflict target
+		quoted := quoteKeywords(h, columns)
+		sets := make([]string, len(quoted))
+		for i, c := range quoted {
+			sets[i] = fmt.Sprintf("%s = excluded.%s", c, c)
+		}
+		return insertHead(h, "INSERT INTO", table, columns), " ON CONFLICT DO UPDATE SET " + strings.Join(sets, ", "), nil
+	case StrategyReplace:
+		return insertHead(h, "REPLACE INTO", table, columns), "", nil
+	}
+	return "", "", fmt.Errorf("testfixtures: unknown insert strategy %d", strategy)
+}
+
+// cleanTables always deletes, SQLite has no TRUNCATE but optimizes a
+// DELETE without WHERE clause the same way
+func (h *sqlite) cleanTables(tx *sql.Tx, tables []string, _ bool) error {
+	for _, table := range tables {
+		if _, err := tx.Exec("DELETE FROM " + h.quoteKeyword(table)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (*sqlite) primaryKey(db *sql.DB, table string) ([]string, error) {
+	return queryStrings(db, "SELECT name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk", table)
+}
+
+// insertReturning reads the rowid, which is the INTEGER PRIMARY KEY column
+func (*sqlite) insertReturning(tx *sql.Tx, query string, params []interface{}, _ string) (interface{}, error) {
+	return lastInsertID(tx, query, params)
+}
+
+// foreignKeys reads pragma_foreign_key_list, a constraint without "to"
+// columns references the primary key of the parent table
+func (h *sqlite) foreignKeys(db *sql.DB) ([]foreignKey, error) {
+	tables, err := h.tableNames(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []foreignKey
+	for _, table := range tables {
+		rows, err := db.Query(`SELECT 'fk' || id, ?, "from", "table", COALESCE("to", '') FROM pragma_foreign_key_list(?) ORDER BY id, seq`, table, table)
+		if err != nil {
+			return nil, err
+		}
+		tableKeys, err := scanForeignKeys(rows)
+		if err != nil {
+			return nil, err
+		}
+		for i, k := range tableKeys {
+			if k.refColumns[0] != "" {
+				continue
+			}
+			if tableKeys[i].refColumns, err = h.primaryKey(db, k.refTable); err != nil {
+				return nil, err
+			}
+		}
+		keys = append(keys, tableKeys...)
+	}
+	return keys, nil
+}
+
+// columns reads the declared types, an INTEGER PRIMARY KEY is the rowid
+// generated by SQLite and every integer is stored on 64 bits
+func (*sqlite) columns(db *sql.DB, table string) ([]column, error) {
+	rows, err := db.Query(`SELECT name, type, "notnull" = 0, dflt_value IS NOT NULL, pk FROM pragma_table_info(?) ORDER BY cid`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		columns []column
+		keys    int
+		rowid   = -1
+	)
+	for rows.Next() {
+		var (
+			c        column
+			declared string
+			pk       int
+		)
+		if err = rows.Scan(&c.name, &declared, &c.nullable, &c.hasDefault, &pk); err != nil {
+			return nil, err
+		}
+		c.dataType, c.length, c.unsigned = parseColumnType(declared)
+		if strings.Contains(c.dataType, "int") {
+			c.dataType = "bigint"
+		}
+		if pk > 0 {
+			keys++
+			if strings.EqualFold(strings.TrimSpace(declared), "integer") {
+				rowid = len(columns)
+			}
+		}
+		columns = append(columns, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if keys == 1 && rowid >= 0 {
+		columns[rowid].hasDefault = true
+	}
+	return columns, nil
+}
+
+// disableReferentialIntegrity pins a connection because PRAGMA foreign_keys
+// is a no-op inside a transaction and only applies to its own connection
+func (*sqlite) disableReferentialIntegrity(db *sql.DB, loadFn loadFunction) (err error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var enabled bool
+	if err = conn.QueryRowContext(ctx, "PRAGMA foreign_keys").Scan(&enabled); err != nil {
+		return err
+	}
+	if enabled {
+		if _, err = conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+			return err
+		}
+		defer func() {
+			if _, err2 := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err == nil {
+				err = err2
+			}
+		}()
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = loadFn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (*sqlite) afterLoad(*sql.Tx) error {
+	return nil
+}
+
+// resetSequences updates sqlite_sequence, only the tables declared with
+// AUTOINCREMENT have a counter, the others always reuse max(rowid)+1
+func (h *sqlite) resetSequences(db *sql.DB, tables []string, starts map[string]int64) error {
+	for _, table := range tables {
+		var autoIncrement bool
+		err := db.QueryRow("SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ? AND sql LIKE '%AUTOINCREMENT%'", table).Scan(&autoIncrement)
+		if err != nil {
+			return err
+		}
+		if !autoIncrement {
+			continue
+		}
+
+		// the counter holds the last id given, not the next one
+		if _, err := db.Exec("DELETE FROM sqlite_sequence WHERE name = ?", table); err != nil {
+			return err
+		}
+		query := fmt.Sprintf("INSERT INTO sqlite_sequence (name, seq) SELECT ?, MAX(COALESCE(MAX(rowid), 0), ?) FROM %s", h.quoteKeyword(table))
+		if _, err := db.Exec(query, table, starts[table]-1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,580 @@
+package fixtures
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openSQLite(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "testfixtures.db")+"?_foreign_keys=1")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		db.Close()
+	})
+	return db
+}
+
+func Test_sqlite_databaseName(t *testing.T) {
+	h := &sqlite{}
+	s, err := h.databaseName(openSQLite(t))
+	assert.NoError(t, err)
+	assert.Equal(t, "testfixtures.db", s)
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	s, err = h.databaseName(db)
+	assert.NoError(t, err)
+	assert.Equal(t, sqliteMemory, s)
+}
+
+func Test_sqlite_disableReferentialIntegrity(t *testing.T) {
+	db := openSQLite(t)
+	db.SetMaxOpenConns(1)
+	_, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY); CREATE TABLE members (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users (id))")
+	require.NoError(t, err)
+
+	h := &sqlite{}
+	err = h.disableReferentialIntegrity(db, func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO members (id, user_id) VALUES (1, 3)")
+		return err
+	})
+	assert.NoError(t, err)
+
+	var enabled bool
+	require.NoError(t, db.QueryRow("PRAGMA foreign_keys").Scan(&enabled))
+	assert.True(t, enabled)
+
+	_, err = db.Exec("INSERT INTO members (id, user_id) VALUES (2, 4)")
+	assert.Error(t, err)
+}
+
+func TestLoader_LoadSQLite(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  user_name VARCHAR(50) NOT NULL DEFAULT '',
+  email VARCHAR(100) NOT NULL DEFAULT '',
+  real_name VARCHAR(50) NOT NULL DEFAULT '',
+  password VARCHAR(64) NOT NULL DEFAULT '',
+  avatar VARCHAR(100) NOT NULL DEFAULT '',
+  status INTEGER NOT NULL DEFAULT 1,
+  about VARCHAR(255) NOT NULL DEFAULT '',
+  role VARCHAR(30) NOT NULL DEFAULT 'user',
+  organization VARCHAR(50) NOT NULL DEFAULT '',
+  created_at DATETIME NOT NULL,
+  updated_at DATETIME NOT NULL
+)`)
+	require.NoError(t, err)
+
+	f, err := New(Database(db), Dialect("sqlite3"), Files("../testdata/fixtures/users.yml"))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	// loading twice replaces the rows
+	require.NoError(t, f.Load())
+
+	var email string
+	require.NoError(t, db.QueryRow("select email from users where id = 1").Scan(&email))
+	assert.Equal(t, "test@test.cn", email)
+}
+
+// writeFixture writes a fixture file into dir and returns its path
+func writeFixture(t *testing.T, dir, name, content string) string {
+	file := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(file, []byte(content), 0644))
+	return file
+}
+
+func TestLoader_LoadSQLite_heterogeneousRecords(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (
+  id INTEGER PRIMARY KEY,
+  name VARCHAR(50) NOT NULL,
+  role VARCHAR(30) NOT NULL DEFAULT 'user'
+)`)
+	require.NoError(t, err)
+
+	file := writeFixture(t, t.TempDir(), "users.yml", `
+- id: 1
+  name: alice
+- id: 2
+  name: bob
+  role: admin
+- id: 3
+  name: carol
+`)
+	f, err := New(Database(db), Dialect("sqlite3"), Files(file))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+
+	rows, err := db.Query("select role from users order by id")
+	require.NoError(t, err)
+	defer rows.Close()
+	var roles []string
+	for rows.Next() {
+		var role string
+		require.NoError(t, rows.Scan(&role))
+		roles = append(roles, role)
+	}
+	assert.Equal(t, []string{"user", "admin", "user"}, roles)
+}
+
+func TestLoader_LoadSQLite_batches(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec("CREATE TABLE numbers (id INTEGER PRIMARY KEY)")
+	require.NoError(t, err)
+
+	// more rows than bind variables allowed in a statement
+	var content strings.Builder
+	for i := 1; i <= 40000; i++ {
+		fmt.Fprintf(&content, "- id: %d\n", i)
+	}
+	file := writeFixture(t, t.TempDir(), "numbers.yml", content.String())
+
+	f, err := New(Database(db), Dialect("sqlite3"), Files(file))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+
+	var ct int
+	require.NoError(t, db.QueryRow("select count(1) from numbers").Scan(&ct))
+	assert.Equal(t, 40000, ct)
+}
+
+func TestLoader_LoadSQLite_insertStrategy(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (
+  id INTEGER PRIMARY KEY,
+  name VARCHAR(50) NOT NULL UNIQUE,
+  role VARCHAR(30) NOT NULL DEFAULT 'user'
+);
+INSERT INTO users (id, name, role) VALUES (2, 'bob', 'admin')`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	file := writeFixture(t, dir, "users.yml", `
+- id: 1
+  name: alice
+- id: 2
+  name: bob
+- id: 3
+  name: carol
+`)
+	role := func() string {
+		var role string
+		require.NoError(t, db.QueryRow("select role from users where id = 2").Scan(&role))
+		return role
+	}
+
+	f, err := New(Database(db), Dialect("sqlite3"), Files(file), Insert(StrategyInsert))
+	require.NoError(t, err)
+	err = f.Load()
+	var insertErr *InsertError
+	require.ErrorAs(t, err, &insertErr)
+	assert.Equal(t, "users.yml", insertErr.File)
+	assert.Equal(t, 1, insertErr.Record)
+	assert.Contains(t, err.Error(), "on file: users.yml, record: 1")
+
+	f, err = New(Database(db), Dialect("sqlite3"), Files(file), Insert(StrategyInsertIgnore, "users.yml"))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	assert.Equal(t, "admin", role())
+
+	f, err = New(Database(db), Dialect("sqlite3"), Files(file), Insert(StrategyUpsert, file))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	// the upsert only updates the columns of the fixture
+	assert.Equal(t, "admin", role())
+
+	f, err = New(Database(db), Dialect("sqlite3"), Files(file), Insert(StrategyInsert), Insert(StrategyReplace, "users.yml"))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	assert.Equal(t, "user", role())
+}
+
+func TestLoader_LoadSQLite_clean(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL);
+CREATE TABLE roles (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL);
+CREATE TABLE logs (id INTEGER PRIMARY KEY, message VARCHAR(50) NOT NULL);
+INSERT INTO users (id, name) VALUES (9, 'left over');
+INSERT INTO roles (id, name) VALUES (1, 'admin');
+CREATE TABLE dbunit_metadata (test_name VARCHAR(255) NOT NULL);
+INSERT INTO logs (id, message) VALUES (1, 'left over');
+INSERT INTO dbunit_metadata (test_name) VALUES ('TestLoader_LoadSQLite_clean')`)
+	require.NoError(t, err)
+
+	file := writeFixture(t, t.TempDir(), "users.yml", "- id: 1\n  name: alice\n")
+	count := func(table string) int {
+		var ct int
+		require.NoError(t, db.QueryRow("select count(1) from "+table).Scan(&ct))
+		return ct
+	}
+
+	f, err := New(Database(db), Dialect("sqlite3"), Files(file), Clean(CleanDeleteFixtures))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	assert.Equal(t, 1, count("users"))
+	assert.Equal(t, 1, count("logs"))
+
+	f, err = New(Database(db), Dialect("sqlite3"), Files(file), Clean(CleanTruncateAll, "roles"))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	assert.Equal(t, 1, count("users"))
+	assert.Equal(t, 1, count("roles"))
+	assert.Equal(t, 0, count("logs"))
+	assert.Equal(t, 1, count("dbunit_metadata"))
+
+	f, err = New(Database(db), Dialect("sqlite3"), Files(file), Clean(CleanDeleteAll))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	assert.Equal(t, 0, count("roles"))
+	assert.Equal(t, 1, count("dbunit_metadata"))
+
+	_, err = New(Database(db), Dialect("sqlite3"), Clean(CleanStrategy(9)))
+	assert.Error(t, err)
+}
+
+func TestLoader_LoadSQLite_resetSequences(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name VARCHAR(50) NOT NULL);
+CREATE TABLE roles (id INTEGER PRIMARY KEY AUTOINCREMENT, name VARCHAR(50) NOT NULL);
+INSERT INTO users (id, name) VALUES (100, 'removed');
+DELETE FROM users`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	users := writeFixture(t, dir, "users.yml", "- id: 1\n  name: alice\n- id: 2\n  name: bob\n")
+	roles := writeFixture(t, dir, "roles.yml", "- id: 1\n  name: admin\n")
+	nextID := func(table string) int64 {
+		res, err := db.Exec("INSERT INTO " + table + " (name) VALUES ('new')")
+		require.NoError(t, err)
+		id, err := res.LastInsertId()
+		require.NoError(t, err)
+		return id
+	}
+
+	f, err := New(Database(db), Dialect("sqlite3"), Files(users, roles), Clean(CleanDeleteFixtures),
+		ResetSequences(map[string]int64{"roles": 1000}))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	assert.Equal(t, int64(3), nextID("users"))
+	assert.Equal(t, int64(1000), nextID("roles"))
+
+	_, err = New(Database(db), Dialect("sqlite3"), ResetSequences(map[string]int64{"roles": 0}))
+	assert.Error(t, err)
+}
+
+func TestLoader_LoadSQLite_multipleTables(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL, role VARCHAR(30) NOT NULL DEFAULT 'user');
+CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER NOT NULL REFERENCES users (id));
+INSERT INTO users (id, name) VALUES (9, 'left over');
+INSERT INTO orders (id, user_id) VALUES (9, 9)`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	shop := writeFixture(t, dir, "shop.yml", "users:\n  - id: 1\n    name: alice\norders:\n  - id: 1\n    user_id: 1\n  - id: 2\n    user_id: 1\n")
+	admins := writeFixture(t, dir, "admins.yml", "_table: users\n_records:\n  - id: 2\n    name: bob\n    role: admin\n")
+	count := func(query string) int {
+		var ct int
+		require.NoError(t, db.QueryRow(query).Scan(&ct))
+		return ct
+	}
+
+	f, err := New(Database(db), Dialect("sqlite3"), Files(shop, admins), Clean(CleanDeleteFixtures))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	assert.Equal(t, 2, count("select count(1) from users"))
+	assert.Equal(t, 1, count("select count(1) from users where role = 'admin'"))
+	assert.Equal(t, 2, count("select count(1) from orders"))
+	assert.Equal(t, 0, count("select count(1) from orders where id = 9"))
+}
+
+func TestLoader_LoadSQLite_references(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name VARCHAR(50) NOT NULL);
+CREATE TABLE docs (id INTEGER PRIMARY KEY, title VARCHAR(50) NOT NULL);
+CREATE TABLE members (id INTEGER PRIMARY KEY, doc_id INTEGER NOT NULL REFERENCES docs (id), user_id INTEGER NOT NULL REFERENCES users (id));
+INSERT INTO users (id, name) VALUES (40, 'left over')`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	members := writeFixture(t, dir, "members.yml", `- id: 1
+  doc_id: $ref(docs.guide)
+  user_id: $ref(users.alice)
+- id: 2
+  doc_id: {{ref "docs" "guide"}}
+  user_id: $ref(users.bob)
+`)
+	users := writeFixture(t, dir, "users.yml", "- _label: alice\n  name: alice\n- _label: bob\n  name: bob\n- name: carol\n")
+	docs := writeFixture(t, dir, "docs.yml", "- _label: guide\n  id: 7\n  title: guide\n")
+
+	f, err := New(Database(db), Dialect("sqlite3"), Files(members, users, docs))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+
+	rows, err := db.Query("SELECT m.id, d.title, u.name FROM members m JOIN docs d ON d.id = m.doc_id JOIN users u ON u.id = m.user_id ORDER BY m.id")
+	require.NoError(t, err)
+	defer rows.Close()
+	var got []string
+	for rows.Next() {
+		var (
+			id          int
+			title, name string
+		)
+		require.NoError(t, rows.Scan(&id, &title, &name))
+		got = append(got, fmt.Sprintf("%d %s %s", id, title, name))
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []string{"1 guide alice", "2 guide bob"}, got)
+
+	unresolved := writeFixture(t, dir, "orphans.yml", "_table: members\n_records:\n  - id: 3\n    doc_id: $ref(docs.guide)\n    user_id: $ref(users.dave)\n")
+	_, err = New(Database(db), Dialect("sqlite3"), Files(users, docs, unresolved))
+	var refErr *ReferenceError
+	if assert.ErrorAs(t, err, &refErr) {
+		assert.Equal(t, "testfixtures: unresolved reference $ref(users.dave), on file: orphans.yml, table: members, record: 0, column: user_id", err.Error())
+	}
+
+	_, err = New(Database(db), Dialect("sqlite3"), Files(users, users))
+	assert.EqualError(t, err, "testfixtures: duplicate label alice of table users in file users.yml, record 0, already defined in file users.yml, record 0")
+}
+
+func TestLoader_LoadSQLite_selfReferences(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec("CREATE TABLE categories (id INTEGER PRIMARY KEY, parent_id INTEGER REFERENCES categories (id))")
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	categories := writeFixture(t, dir, "categories.yml", `- _label: root
+  id: 1
+  parent_id: null
+- _label: child
+  id: 2
+  parent_id: $ref(categories.root)
+- id: 3
+  parent_id: $ref(categories.child)
+`)
+	f, err := New(Database(db), Dialect("sqlite3"), Files(categories))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+
+	var parents []sql.NullInt64
+	rows, err := db.Query("SELECT parent_id FROM categories ORDER BY id")
+	require.NoError(t, err)
+	defer rows.Close()
+	for rows.Next() {
+		var p sql.NullInt64
+		require.NoError(t, rows.Scan(&p))
+		parents = append(parents, p)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, []sql.NullInt64{{}, {Int64: 1, Valid: true}, {Int64: 2, Valid: true}}, parents)
+}
+
+func TestLoader_LoadSQLite_checkForeignKeys(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE regions (code VARCHAR(10) PRIMARY KEY);
+CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL, region VARCHAR(10) REFERENCES regions);
+CREATE TABLE docs (id INTEGER PRIMARY KEY, title VARCHAR(50) NOT NULL);
+CREATE TABLE members (id INTEGER PRIMARY KEY, doc_id INTEGER NOT NULL, user_id INTEGER NOT NULL,
+  FOREIGN KEY (doc_id) REFERENCES docs (id), FOREIGN KEY (user_id) REFERENCES users (id));
+INSERT INTO regions (code) VALUES ('eu')`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	members := writeFixture(t, dir, "members.yml", "- id: 1\n  doc_id: 1\n  user_id: 1\n- id: 2\n  doc_id: 1\n  user_id: 2\n")
+	users := writeFixture(t, dir, "users.yml", "- id: 1\n  name: alice\n  region: eu\n- id: 2\n  name: bob\n")
+	docs := writeFixture(t, dir, "docs.yml", "- id: 1\n  title: guide\n")
+
+	f, err := New(Database(db), Dialect("sqlite3"), Files(members, users, docs), CheckForeignKeys())
+	require.NoError(t, err)
+	tables := make([]string, len(f.sections))
+	for i, s := range f.sections {
+		tables[i] = s.table
+	}
+	assert.Equal(t, []string{"users", "docs", "members"}, tables)
+	require.NoError(t, f.Load())
+
+	var ct int
+	require.NoError(t, db.QueryRow("select count(1) from members").Scan(&ct))
+	assert.Equal(t, 2, ct)
+
+	dangling := writeFixture(t, dir, "dangling.yml", `users:
+  - id: 3
+    name: carol
+    region: us
+members:
+  - id: 3
+    doc_id: 1
+    user_id: 2
+  - id: 4
+    doc_id: 9
+    user_id: 8
+`)
+	f, err = New(Database(db), Dialect("sqlite3"), Files(dangling), CheckForeignKeys())
+	require.NoError(t, err)
+	err = f.Load()
+	var fkErr *ForeignKeyError
+	if assert.ErrorAs(t, err, &fkErr) {
+		assert.Equal(t, `testfixtures: 3 dangling foreign keys:
+	file: dangling.yml, table: users, record: 0, (region) = (us) references no row of regions (code)
+	file: dangling.yml, table: members, record: 1, (user_id) = (8) references no row of users (id)
+	file: dangling.yml, table: members, record: 1, (doc_id) = (9) references no row of docs (id)`, err.Error())
+	}
+	require.NoError(t, db.QueryRow("select count(1) from users").Scan(&ct))
+	assert.Equal(t, 2, ct)
+
+	// nothing is cleaned when the check fails
+	f, err = New(Database(db), Dialect("sqlite3"), Files(dangling), CheckForeignKeys(), Clean(CleanDeleteAll, "regions"))
+	require.NoError(t, err)
+	assert.ErrorAs(t, f.Load(), &fkErr)
+	require.NoError(t, db.QueryRow("select count(1) from users").Scan(&ct))
+	assert.Equal(t, 2, ct)
+	require.NoError(t, db.QueryRow("select count(1) from members").Scan(&ct))
+	assert.Equal(t, 2, ct)
+
+	// the rows of the cleaned tables are not parents
+	orphans := writeFixture(t, dir, "orphans.yml", "_table: members\n_records:\n  - id: 5\n    doc_id: 1\n    user_id: 2\n")
+	f, err = New(Database(db), Dialect("sqlite3"), Files(orphans, docs), CheckForeignKeys(), Clean(CleanDeleteAll, "regions"))
+	require.NoError(t, err)
+	if assert.ErrorAs(t, f.Load(), &fkErr) {
+		require.Len(t, fkErr.Dangling, 1)
+		assert.Equal(t, "users", fkErr.Dangling[0].RefTable)
+	}
+	require.NoError(t, db.QueryRow("select count(1) from users").Scan(&ct))
+	assert.Equal(t, 2, ct)
+}
+
+func TestLoader_ValidateSQLite(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (
+  id INTEGER PRIMARY KEY,
+  name VARCHAR(10) NOT NULL,
+  status TINYINT NOT NULL DEFAULT 1,
+  created_at DATETIME NOT NULL,
+  about TEXT
+)`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	valid := writeFixture(t, dir, "users.yml", "- name: alice\n  created_at: 2020-01-02 10:00:00\n- id: 5\n  name: bob\n  status: 2\n  created_at: 2020-01-02\n  about: ~\n")
+	f, err := New(Database(db), Dialect("sqlite3"), Files(valid))
+	require.NoError(t, err)
+	assert.NoError(t, f.Validate())
+
+	invalid := writeFixture(t, dir, "invalid.yml", `users:
+  - name: a name much too long
+    created_at: 2020-02-30
+  - nmae: carol
+    status: high
+    created_at: 2020-01-02
+roles:
+  - id: 1
+`)
+	f, err = New(Database(db), Dialect("sqlite3"), Files(invalid))
+	require.NoError(t, err)
+	err = f.Validate()
+	var validationErr *ValidationError
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, `testfixtures: 6 invalid fixture values:
+	file: invalid.yml, table: users, record: 0, column: created_at: "2020-02-30" is not a date
+	file: invalid.yml, table: users, record: 0, column: name: 20 characters are longer than varchar(10)
+	file: invalid.yml, table: users, record: 1, column: nmae: unknown column
+	file: invalid.yml, table: users, record: 1, column: status: "high" is not an integer
+	file: invalid.yml, table: users, record: 1, column: name: missing value of NOT NULL column without default
+	file: invalid.yml, table: roles: unknown table`, err.Error())
+	}
+}
+
+func TestLoader_LoadSQLite_json(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL, profile TEXT, created_at DATETIME NOT NULL);
+CREATE TABLE roles (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL)`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	writeFixture(t, dir, "users.json", `[
+	{"id": 1, "name": "alice", "profile": {"tags": ["admin"]}, "created_at": "2020-01-02 10:00:00"},
+	{"id": 2, "name": "bob", "created_at": "{{now}}"}
+]`)
+	writeFixture(t, dir, "shop.json", `{"roles": [{"id": 1, "name": "admin"}]}`)
+	writeFixture(t, dir, "notes.txt", "not a fixture")
+
+	f, err := New(Database(db), Dialect("sqlite3"), Directory(dir))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+
+	var profile string
+	require.NoError(t, db.QueryRow("SELECT profile FROM users WHERE id = 1").Scan(&profile))
+	assert.JSONEq(t, `{"tags": ["admin"]}`, profile)
+	var created time.Time
+	require.NoError(t, db.QueryRow("SELECT created_at FROM users WHERE id = 2").Scan(&created))
+	assert.WithinDuration(t, time.Now(), created, time.Minute)
+	var ct int
+	require.NoError(t, db.QueryRow("SELECT count(1) FROM roles").Scan(&ct))
+	assert.Equal(t, 1, ct)
+
+	broken := writeFixture(t, t.TempDir(), "broken.json", `[{"id": 1,}]`)
+	_, err = New(Database(db), Dialect("sqlite3"), Files(broken))
+	assert.ErrorContains(t, err, "testfixtures: could not unmarshal JSON file broken.json")
+}
+
+func TestLoader_LoadSQLite_csv(t *testing.T) {
+	db := openSQLite(t)
+	_, err := db.Exec(`CREATE TABLE regions (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL, rate REAL, active BOOLEAN, created_at DATETIME, note TEXT);
+CREATE TABLE users (id INTEGER PRIMARY KEY, region_id INTEGER NOT NULL REFERENCES regions (id))`)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	var b strings.Builder
+	b.WriteString("id;name;rate;active;created_at;note\n")
+	b.WriteString("1;\"Europe; West\";1.5;true;2020-01-02 10:00:00;NULL\n")
+	for i := 2; i <= 2500; i++ {
+		fmt.Fprintf(&b, "%d;region %d;%d;false;2020-01-02;\"said \"\"hi\"\"\"\n", i, i, i)
+	}
+	writeFixture(t, dir, "regions.csv", b.String())
+	writeFixture(t, dir, "users.yml", "- id: 1\n  region_id: 2500\n")
+
+	f, err := New(Database(db), Dialect("sqlite3"), Directory(dir), CSV(CSVOptions{Delimiter: ';', Null: "NULL"}), CheckForeignKeys())
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+
+	var (
+		ct     int
+		name   string
+		rate   float64
+		active bool
+		note   sql.NullString
+	)
+	require.NoError(t, db.QueryRow("SELECT count(1) FROM regions").Scan(&ct))
+	assert.Equal(t, 2500, ct)
+	require.NoError(t, db.QueryRow("SELECT name, rate, active, note FROM regions WHERE id = 1").Scan(&name, &rate, &active, &note))
+	assert.Equal(t, "Europe; West", name)
+	assert.Equal(t, 1.5, rate)
+	assert.True(t, active)
+	assert.False(t, note.Valid)
+	require.NoError(t, db.QueryRow("SELECT note FROM regions WHERE id = 2").Scan(&note))
+	assert.Equal(t, `said "hi"`, note.String)
+
+	invalid := writeFixture(t, t.TempDir(), "regions.csv", "id,name,rate\n1,a,1\n2,b,high\n")
+	f, err = New(Database(db), Dialect("sqlite3"), Files(invalid), Clean(CleanDeleteFixtures))
+	require.NoError(t, err)
+	assert.EqualError(t, f.Load(), `testfixtures: record 1 of CSV file regions.csv, column rate: "high" is not a number`)
+
+	unknown := writeFixture(t, t.TempDir(), "regions.csv", "id,nmae\n1,a\n")
+	_, err = New(Database(db), Dialect("sqlite3"), Files(unknown))
+	assert.EqualError(t, err, "testfixtures: unknown column nmae of table regions in the header of CSV file regions.csv")
+
+	empty := writeFixture(t, t.TempDir(), "regions.csv", "")
+	_, err = New(Database(db), Dialect("sqlite3"), Files(empty))
+	assert.EqualError(t, err, "testfixtures: CSV file regions.csv has no header row")
+}
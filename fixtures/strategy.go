@@ -0,0 +1,49 @@
+package fixtures
+
+import "strings"
+
+// InsertStrategy is the statement used to insert the records, see Insert
+type InsertStrategy int
+
+const (
+	// StrategyInsert uses a plain INSERT, a record conflicting on a unique
+	// key fails the load and is reported in the InsertError
+	StrategyInsert InsertStrategy = iota + 1
+	// StrategyInsertIgnore skips the records conflicting on a unique key
+	StrategyInsertIgnore
+	// StrategyUpsert updates the row a record conflicts with, on PostgreSQL
+	// the conflict is detected on the primary key
+	StrategyUpsert
+	// StrategyReplace deletes the rows a record conflicts with before
+	// inserting it, it is the default on MySQL and SQLite and is not
+	// supported by PostgreSQL
+	StrategyReplace
+)
+
+func (s InsertStrategy) String() string {
+	switch s {
+	case StrategyInsert:
+		return "INSERT"
+	case StrategyInsertIgnore:
+		return "INSERT IGNORE"
+	case StrategyUpsert:
+		return "UPSERT"
+	case StrategyReplace:
+		return "REPLACE"
+	}
+	return "UNKNOWN"
+}
+
+// insertHead returns the beginning of a statement inserting columns into
+// table, up to the VALUES keyword
+func insertHead(h helper, keyword, table string, columns []string) string {
+	return keyword + " " + h.quoteKeyword(table) + "(" + strings.Join(quoteKeywords(h, columns), ", ") + ") VALUES "
+}
+
+func quoteKeywords(h helper, names []string) []string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = h.quoteKeyword(name)
+	}
+	return quoted
+}
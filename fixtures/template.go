@@ -42,6 +42,10 @@ func NewTemplate() *Template {
 		}
 		return time.Now().Add(duration).Format(time.RFC3339)
 	}
+	// ref references the primary key of the record labelled label in table
+	l.templateFuncs["ref"] = func(table, label string) string {
+		return reference{table: table, label: label}.String()
+	}
 	return l
 }
 
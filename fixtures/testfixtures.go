@@ -2,35 +2,83 @@ package fixtures
 
 import (
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"path"
 	"path/filepath"
 	"regexp"
-	"sort"
 	"strings"
 	"time"
-
-	"gopkg.in/yaml.v2"
 )
 
 // Loader is the responsible to loading fixtures.
 type Loader struct {
 	db            *sql.DB
-	helper        *mySQL
+	helper        helper
 	fixturesFiles []*fixtureFile
 
 	skipTestDatabaseCheck bool
 	location              *time.Location
 
 	template *Template
+
+	// batchPlaceholders and batchBytes bound the insert statements, see BatchSize
+	batchPlaceholders int
+	batchBytes        int
+
+	// strategy and fileStrategies are set by Insert
+	strategy       InsertStrategy
+	fileStrategies map[string]InsertStrategy
+
+	// clean and cleanExclude are set by Clean
+	clean        CleanStrategy
+	cleanExclude []string
+
+	// resetSequences and sequenceStarts are set by ResetSequences
+	resetSequences bool
+	sequenceStarts map[string]int64
+
+	// sections are the statements of the files in load order
+	sections []*fixtureSection
+	// keys are the primary keys of the labelled records loaded
+	keys map[reference]interface{}
+
+	// checkForeignKeys is set by CheckForeignKeys, foreignKeys are the
+	// constraints of the database it reads
+	checkForeignKeys bool
+	foreignKeys      []foreignKey
+
+	// csv is set by CSV
+	csv CSVOptions
+
+	// sqlMode is the sql_mode of the load on MySQL when setSQLMode is set
+	sqlMode    string
+	setSQLMode bool
 }
 
 type insertSQL struct {
 	sql    string
 	params []interface{}
+
+	table   string
+	columns []string
+	records []map[string]interface{}
+	// first is the index in the table of the file of the first record inserted
+	first int
+
+	// labels are the labels of the records, primaryKey the column their
+	// references resolve to. The key of a generated insert is read back from
+	// the database, it has a single record without primaryKey.
+	labels     []string
+	primaryKey string
+	generated  bool
 }
 
+// defaultBatchBytes keeps the statements well below the 4MB max_allowed_packet of MySQL 5.7
+const defaultBatchBytes = 1 << 20
+
 var (
 	testDatabaseRegexp    = regexp.MustCompile("(?i)test")
 	errDatabaseIsRequired = fmt.Errorf("testfixtures: database is required")
@@ -53,6 +101,9 @@ func New(options ...func(*Loader) error) (*Loader, error) {
 	if l.db == nil {
 		return nil, errDatabaseIsRequired
 	}
+	if _, ok := l.helper.(*mySQL); l.setSQLMode && !ok {
+		return nil, fmt.Errorf("testfixtures: SQLMode is only supported by MySQL")
+	}
 
 	if err := l.helper.init(l.db); err != nil {
 		return nil, err
@@ -72,7 +123,23 @@ func Database(db *sql.DB) func(*Loader) error {
 	}
 }
 
-// Directory informs Loader to load YAML files from a given directory.
+// Dialect informs Loader about which database dialect you're using,
+// "mysql" is used when not given.
+//
+// Possible options are "mysql", "mariadb", "postgres", "postgresql", "pgx",
+// "sqlite" and "sqlite3".
+func Dialect(dialect string) func(*Loader) error {
+	return func(l *Loader) error {
+		h, err := helperForDialect(dialect)
+		if err != nil {
+			return err
+		}
+		l.helper = h
+		return nil
+	}
+}
+
+// Directory informs Loader to load YAML, JSON and CSV files from a given directory.
 func Directory(dir string) func(*Loader) error {
 	return func(l *Loader) error {
 		fixtures, err := l.fixturesFromDir(dir)
@@ -84,7 +151,9 @@ func Directory(dir string) func(*Loader) error {
 	}
 }
 
-// Files informs Loader to load a given set of YAML files.
+// Files informs Loader to load a given set of YAML, JSON and CSV files, the
+// files ending in .json are decoded as JSON and the ones ending in .csv are
+// read as described by CSV.
 func Files(files ...string) func(*Loader) error {
 	return func(l *Loader) error {
 		fixtures, err := l.fixturesFromFiles(files...)
@@ -96,6 +165,97 @@ func Files(files ...string) func(*Loader) error {
 	}
 }
 
+// BatchSize limits every insert statement to placeholders bind variables and
+// about bytes bytes, the records of a file are split in as many statements
+// as needed. All of them still run in the single transaction of Load. Zero
+// keeps the defaults: the limit of the database for the bind variables and
+// 1MB.
+func BatchSize(placeholders, bytes int) func(*Loader) error {
+	return func(l *Loader) error {
+		if placeholders < 0 || bytes < 0 {
+			return fmt.Errorf("testfixtures: invalid batch size %d placeholders, %d bytes", placeholders, bytes)
+		}
+		l.batchPlaceholders = placeholders
+		l.batchBytes = bytes
+		return nil
+	}
+}
+
+// Insert sets the statement used to insert the records of the given files,
+// or of every file when none is given. Files are matched by path or by base
+// name. Without this option MySQL and SQLite use StrategyReplace and
+// PostgreSQL StrategyInsert.
+func Insert(strategy InsertStrategy, files ...string) func(*Loader) error {
+	return func(l *Loader) error {
+		if strategy < StrategyInsert || strategy > StrategyReplace {
+			return fmt.Errorf("testfixtures: unknown insert strategy %d", strategy)
+		}
+		if len(files) == 0 {
+			l.strategy = strategy
+			return nil
+		}
+		if l.fileStrategies == nil {
+			l.fileStrategies = make(map[string]InsertStrategy)
+		}
+		for _, f := range files {
+			l.fileStrategies[f] = strategy
+		}
+		return nil
+	}
+}
+
+// strategyFor returns the insert strategy of file f
+func (l *Loader) strategyFor(f *fixtureFile) InsertStrategy {
+	if s, ok := l.fileStrategies[f.path]; ok {
+		return s
+	}
+	if s, ok := l.fileStrategies[f.fileName]; ok {
+		return s
+	}
+	if l.strategy != 0 {
+		return l.strategy
+	}
+	return l.helper.defaultInsertStrategy()
+}
+
+// ResetSequences resets the AUTO_INCREMENT counters, or the sequences on
+// PostgreSQL, of the fixture tables and of the tables of starts after
+// loading. The next generated id is max(id)+1, or the start given for the
+// table when it is larger.
+func ResetSequences(starts map[string]int64) func(*Loader) error {
+	return func(l *Loader) error {
+		l.resetSequences = true
+		if l.sequenceStarts == nil {
+			l.sequenceStarts = make(map[string]int64, len(starts))
+		}
+		for table, start := range starts {
+			if start < 1 {
+				return fmt.Errorf("testfixtures: invalid start %d of the sequence of table %s", start, table)
+			}
+			l.sequenceStarts[table] = start
+		}
+		return nil
+	}
+}
+
+// SQLModeStrict rejects the values that don't fit their column, like too
+// long strings and invalid or zero dates, instead of adjusting them
+const SQLModeStrict = "STRICT_TRANS_TABLES,STRICT_ALL_TABLES,NO_ZERO_IN_DATE,NO_ZERO_DATE,ERROR_FOR_DIVISION_BY_ZERO,NO_ENGINE_SUBSTITUTION"
+
+// SQLMode sets the sql_mode of the MySQL session loading the fixtures, on
+// the connection of the load transaction, the previous mode is restored
+// afterwards. Without this option the sql_mode of the server applies, ""
+// lets MySQL truncate and coerce the invalid values silently.
+//
+//	fixtures.SQLMode(fixtures.SQLModeStrict)
+func SQLMode(mode string) func(*Loader) error {
+	return func(l *Loader) error {
+		l.sqlMode = mode
+		l.setSQLMode = true
+		return nil
+	}
+}
+
 // Location makes Loader use the given location by default when parsing
 // dates. If not given, by default it uses the value of time.Local.
 func Location(location *time.Location) func(*Loader) error {
@@ -112,16 +272,22 @@ func (l *Loader) EnsureTestDatabase() error {
 	if err != nil {
 		return err
 	}
+	// an in-memory database can never hold production data
+	if dbName == sqliteMemory {
+		return nil
+	}
 	if !testDatabaseRegexp.MatchString(dbName) {
 		return fmt.Errorf(`testfixtures: database "%s" does not appear to be a test database`, dbName)
 	}
 	return nil
 }
 
-// Load wipes and after load all fixtures in the database.
-//     if err := fixtures.Load(); err != nil {
-//             ...
-//     }
+// Load loads all fixtures in the database, the tables are emptied first
+// when the Clean option is given.
+//
+//	if err := fixtures.Load(); err != nil {
+//		...
+//	}
 func (l *Loader) Load() error {
 	if !l.skipTestDatabaseCheck {
 		if err := l.EnsureTestDatabase(); err != nil {
@@ -129,97 +295,495 @@ func (l *Loader) Load() error {
 		}
 	}
 
-	_, _ = l.db.Exec("set @@sql_mode=''")
+	tables, err := l.tablesToClean()
+	if err != nil {
+		return err
+	}
 
-	err := l.helper.disableReferentialIntegrity(l.db, func(tx *sql.Tx) error {
-		for _, file := range l.fixturesFiles {
-			if _, err := tx.Exec(file.insertSQL.sql, file.insertSQL.params...); err != nil {
-				return &InsertError{
-					Err:    err,
-					File:   file.fileName,
-					SQL:    file.insertSQL.sql,
-					Params: file.insertSQL.params,
+	if l.checkForeignKeys {
+		err = l.loadChecked(tables)
+	} else {
+		err = l.helper.disableReferentialIntegrity(l.db, func(tx *sql.Tx) error {
+			return l.withSQLMode(tx, func() error {
+				if err := l.cleanTables(tx, tables); err != nil {
+					return err
+				}
+				if err := l.insertAll(tx); err != nil {
+					return err
 				}
+				return l.helper.afterLoad(tx)
+			})
+		})
+	}
+	if err != nil || !l.resetSequences {
+		return err
+	}
+
+	tables = l.fixtureTables()
+	for table := range l.sequenceStarts {
+		if !containsString(tables, table) {
+			tables = append(tables, table)
+		}
+	}
+	if err := l.helper.resetSequences(l.db, tables, l.sequenceStarts); err != nil {
+		return fmt.Errorf("testfixtures: could not reset sequences: %w", err)
+	}
+	return nil
+}
+
+// loadChecked loads the fixtures with the foreign keys checked, after
+// making sure that none of them is dangling. The check comes first so that
+// nothing is cleaned when it fails, the rows of the tables to clean don't
+// count as parents.
+func (l *Loader) loadChecked(tables []string) error {
+	if err := l.checkDangling(tables); err != nil {
+		return err
+	}
+	if len(tables) > 0 {
+		err := l.helper.disableReferentialIntegrity(l.db, func(tx *sql.Tx) error {
+			return l.cleanTables(tx, tables)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	tx, err := l.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = l.withSQLMode(tx, func() error {
+		if err := l.insertAll(tx); err != nil {
+			return err
+		}
+		return l.helper.afterLoad(tx)
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkDangling returns a ForeignKeyError listing the dangling references,
+// in a read only transaction ended before the tables are cleaned
+func (l *Loader) checkDangling(cleaned []string) error {
+	tx, err := l.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	dangling, err := l.danglingReferences(tx, cleaned)
+	if err != nil {
+		return err
+	}
+	if len(dangling) > 0 {
+		return &ForeignKeyError{Dangling: dangling}
+	}
+	return nil
+}
+
+// withSQLMode runs fn with the sql_mode of the SQLMode option set on the
+// connection of tx. The session variable outlives the transaction so the
+// previous mode is restored before the connection goes back to the pool.
+func (l *Loader) withSQLMode(tx *sql.Tx, fn func() error) (err error) {
+	if !l.setSQLMode {
+		return fn()
+	}
+
+	var previous string
+	if err := tx.QueryRow("SELECT @@SESSION.sql_mode").Scan(&previous); err != nil {
+		return fmt.Errorf("testfixtures: could not read sql_mode: %w", err)
+	}
+	if _, err := tx.Exec("SET SESSION sql_mode = ?", l.sqlMode); err != nil {
+		return fmt.Errorf("testfixtures: could not set sql_mode %q: %w", l.sqlMode, err)
+	}
+	defer func() {
+		if _, err2 := tx.Exec("SET SESSION sql_mode = ?", previous); err == nil && err2 != nil {
+			err = fmt.Errorf("testfixtures: could not restore sql_mode %q: %w", previous, err2)
+		}
+	}()
+	return fn()
+}
+
+// insertAll runs the inserts of every section in order
+func (l *Loader) insertAll(tx *sql.Tx) error {
+	l.keys = make(map[reference]interface{})
+	for _, section := range l.sections {
+		if section.csv != nil {
+			if err := l.loadCSV(tx, section); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, insert := range section.inserts {
+			if err := l.exec(tx, section.file, insert); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// fixtureTables returns the tables having a fixture file
+func (l *Loader) fixtureTables() []string {
+	var (
+		tables []string
+		seen   = make(map[string]bool)
+	)
+	for _, f := range l.fixturesFiles {
+		for _, table := range f.tableNames {
+			if !seen[table] {
+				seen[table] = true
+				tables = append(tables, table)
 			}
 		}
+	}
+	return tables
+}
+
+// exec runs insert. With StrategyInsert a failing statement is retried
+// record by record in a savepoint to report the offending record.
+func (l *Loader) exec(tx *sql.Tx, file *fixtureFile, insert insertSQL) error {
+	insertError := func(err error, insert insertSQL, params []interface{}) *InsertError {
+		record := -1
+		if len(insert.records) == 1 {
+			record = insert.first
+		}
+		return &InsertError{
+			Err:    err,
+			File:   file.fileName,
+			Record: record,
+			SQL:    insert.sql,
+			Params: params,
+		}
+	}
+
+	params, err := l.resolve(file, insert)
+	if err != nil {
+		return err
+	}
+
+	if insert.generated {
+		id, err := l.helper.insertReturning(tx, insert.sql, params, insert.primaryKey)
+		if err != nil {
+			return insertError(err, insert, params)
+		}
+		l.register(insert, params, id)
 		return nil
-	})
-	return err
+	}
+
+	if file.strategy != StrategyInsert || len(insert.records) <= 1 {
+		if _, err := tx.Exec(insert.sql, params...); err != nil {
+			return insertError(err, insert, params)
+		}
+		l.register(insert, params, nil)
+		return nil
+	}
+
+	if _, err := tx.Exec("SAVEPOINT testfixtures_insert"); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(insert.sql, params...); err == nil {
+		l.register(insert, params, nil)
+		_, err = tx.Exec("RELEASE SAVEPOINT testfixtures_insert")
+		return err
+	}
+	if _, err := tx.Exec("ROLLBACK TO SAVEPOINT testfixtures_insert"); err != nil {
+		return err
+	}
+
+	for i := range insert.records {
+		rows, rowErr := l.buildInsertSQL(file.strategy, insert.table, recordGroup{
+			first:   insert.first + i,
+			columns: insert.columns,
+			records: insert.records[i : i+1],
+		})
+		if rowErr != nil {
+			return rowErr
+		}
+		rowParams, rowErr := l.resolve(file, rows[0])
+		if rowErr != nil {
+			return rowErr
+		}
+		if _, rowErr = tx.Exec(rows[0].sql, rowParams...); rowErr != nil {
+			return insertError(rowErr, rows[0], rowParams)
+		}
+	}
+	return insertError(err, insert, params)
 }
 
 // InsertError will be returned if any error happens on database while
 // inserting the record.
 type InsertError struct {
-	Err    error
-	File   string
+	Err  error
+	File string
+	// Record is the index of the failing record in the file, -1 when the
+	// statement inserted several records
+	Record int
 	SQL    string
 	Params []interface{}
 }
 
 func (e *InsertError) Error() string {
+	record := ""
+	if e.Record >= 0 {
+		record = fmt.Sprintf(", record: %d", e.Record)
+	}
 	return fmt.Sprintf(
-		"testfixtures: error inserting record: %v, on file: %s, sql: %s, params: %v",
+		"testfixtures: error inserting record: %v, on file: %s%s, sql: %s, params: %v",
 		e.Err,
 		e.File,
+		record,
 		e.SQL,
 		e.Params,
 	)
 }
 
+func (e *InsertError) Unwrap() error {
+	return e.Err
+}
+
+// buildInsertSQLs builds the statements of every file and sorts them so
+// that labelled records are inserted before the records referencing them
 func (l *Loader) buildInsertSQLs() error {
+	var (
+		sections []*fixtureSection
+		defined  = make(map[reference]labelled)
+	)
 	for _, f := range l.fixturesFiles {
-		var records []map[string]interface{}
-		if err := yaml.Unmarshal(f.content, &records); err != nil {
-			return fmt.Errorf("testfixtures: could not unmarshal YAML: %w", err)
+		f.strategy = l.strategyFor(f)
+		if f.isCSV() {
+			section, err := l.buildCSVSection(f)
+			if err != nil {
+				return err
+			}
+			f.tableNames = []string{section.table}
+			sections = append(sections, section)
+			continue
 		}
 
-		if len(records) == 0 {
-			continue
+		tables, err := f.tables()
+		if err != nil {
+			return err
 		}
 
-		sqlColumnsQuote := make([]string, 0)
-		sqlValuesBind := make([]string, 0)
-		for k, _ := range records[0] {
-			sqlColumnsQuote = append(sqlColumnsQuote, l.helper.quoteKeyword(k))
-			sqlValuesBind = append(sqlValuesBind, "?")
-		}
-		sqlBind := fmt.Sprintf("(%s)", strings.Join(sqlValuesBind, ", "))
-		sqlBinds := make([]string, len(records))
-		for k, _ := range records {
-			sqlBinds[k] = sqlBind
-		}
-		sort.Strings(sqlColumnsQuote)
-		var (
-			sqlStr = fmt.Sprintf(
-				"REPLACE INTO %s(%s) VALUES %s",
-				l.helper.quoteKeyword(f.fileNameWithoutExtension()),
-				strings.Join(sqlColumnsQuote, ", "),
-				strings.Join(sqlBinds, ", "),
-			)
-			sqlValues = make([]interface{}, 0)
-		)
-
-		for _, record := range records {
-			for _, k := range sqlColumnsQuote {
-				k = strings.Trim(k, "`")
-				switch v := record[k].(type) {
-				case string:
-					if t, err := tryStrToDate(l.location, v); err == nil {
-						record[k] = t
+		f.tableNames = f.tableNames[:0]
+		for _, table := range tables {
+			f.tableNames = append(f.tableNames, table.name)
+			section, err := l.buildSection(f, table, defined)
+			if err != nil {
+				return err
+			}
+			sections = append(sections, section)
+		}
+	}
+
+	for _, section := range sections {
+		for _, insert := range section.inserts {
+			for i, v := range insert.params {
+				r, ok := v.(reference)
+				if !ok {
+					continue
+				}
+				def, ok := defined[r]
+				if !ok {
+					return &ReferenceError{
+						Reference: r.String(),
+						File:      section.file.fileName,
+						Table:     section.table,
+						Record:    insert.first + i/len(insert.columns),
+						Column:    insert.columns[i%len(insert.columns)],
 					}
-				case []interface{}, map[interface{}]interface{}:
-					record[k] = recursiveToJSON(v)
 				}
-				sqlValues = append(sqlValues, record[k])
+				if def.section != section && !containsSection(section.deps, def.section) {
+					section.deps = append(section.deps, def.section)
+				}
 			}
 		}
+	}
 
-		f.insertSQL = insertSQL{sqlStr, sqlValues}
+	var err error
+	if l.sections, err = orderSections(sections); err != nil {
+		return err
 	}
 
+	// without the CheckForeignKeys option, the parent tables only go first
+	// for the PostgreSQL users who can not skip the non deferrable constraints
+	pg, ok := l.helper.(*postgreSQL)
+	if !l.checkForeignKeys && (!ok || pg.superuser) {
+		return nil
+	}
+	if l.foreignKeys, err = l.helper.foreignKeys(l.db); err != nil {
+		return fmt.Errorf("testfixtures: could not read the foreign keys: %w", err)
+	}
+	l.addForeignKeyDeps(sections)
+	ordered, err := orderSections(sections)
+	if err != nil && l.checkForeignKeys {
+		return err
+	}
+	if err == nil {
+		l.sections = ordered
+	}
 	return nil
 }
 
+// buildSection builds the statements inserting the records of table from
+// file f and adds its labels to defined
+func (l *Loader) buildSection(f *fixtureFile, table fixtureTable, defined map[reference]labelled) (*fixtureSection, error) {
+	section := &fixtureSection{file: f, table: table.name}
+	labels, err := prepareReferences(f, table)
+	if err != nil {
+		return nil, err
+	}
+
+	var primaryKey string
+	for i, label := range labels {
+		if label == "" {
+			continue
+		}
+		if primaryKey == "" {
+			keys, err := l.helper.primaryKey(l.db, table.name)
+			if err != nil {
+				return nil, err
+			}
+			if len(keys) != 1 {
+				return nil, fmt.Errorf("testfixtures: record %d of table %s in file %s has a label but the table has no single column primary key", i, table.name, f.fileName)
+			}
+			primaryKey = keys[0]
+		}
+		r := reference{table: table.name, label: label}
+		if def, ok := defined[r]; ok {
+			return nil, fmt.Errorf("testfixtures: duplicate label %s of table %s in file %s, record %d, already defined in file %s, record %d",
+				label, table.name, f.fileName, i, def.section.file.fileName, def.record)
+		}
+		defined[r] = labelled{section: section, record: i}
+	}
+
+	for _, group := range groupRecords(table.records) {
+		group.labels = labels[group.first : group.first+len(group.records)]
+		for _, isolated := range isolateGenerated(group, primaryKey) {
+			for _, part := range splitSelfReferences(isolated, table.name) {
+				inserts, err := l.buildInsertSQL(f.strategy, table.name, part)
+				if err != nil {
+					return nil, err
+				}
+				for i := range inserts {
+					inserts[i].primaryKey = primaryKey
+					inserts[i].generated = primaryKey != "" && len(part.records) == 1 &&
+						part.labels[0] != "" && !containsString(part.columns, primaryKey)
+				}
+				section.inserts = append(section.inserts, inserts...)
+			}
+		}
+	}
+	return section, nil
+}
+
+// buildInsertSQL builds the statements inserting the records of group into
+// table, the records are split in batches within the placeholder and size
+// limits
+func (l *Loader) buildInsertSQL(strategy InsertStrategy, table string, group recordGroup) ([]insertSQL, error) {
+	maxPlaceholders := l.helper.maxPlaceholders()
+	if l.batchPlaceholders > 0 && l.batchPlaceholders < maxPlaceholders {
+		maxPlaceholders = l.batchPlaceholders
+	}
+	maxBytes := defaultBatchBytes
+	if l.batchBytes > 0 {
+		maxBytes = l.batchBytes
+	}
+
+	prefix, suffix, err := l.helper.insertClauses(strategy, table, group.columns)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		inserts   []insertSQL
+		sqlBinds  []string
+		sqlValues []interface{}
+		size      int
+		first     int
+	)
+	flush := func(i int) {
+		if len(sqlBinds) > 0 {
+			inserts = append(inserts, insertSQL{
+				sql:     prefix + strings.Join(sqlBinds, ", ") + suffix,
+				params:  sqlValues,
+				table:   table,
+				columns: group.columns,
+				records: group.records[first-group.first : i],
+				first:   first,
+			})
+			if group.labels != nil {
+				inserts[len(inserts)-1].labels = group.labels[first-group.first : i]
+			}
+		}
+		sqlBinds, sqlValues = nil, nil
+	}
+
+	for i, record := range group.records {
+		rowSize := 4 * len(group.columns)
+		for _, k := range group.columns {
+			switch v := record[k].(type) {
+			case string:
+				if t, err := tryStrToDate(l.location, v); err == nil {
+					record[k] = t
+				}
+			case []interface{}, map[interface{}]interface{}:
+				record[k] = recursiveToJSON(v)
+			}
+			rowSize += valueSize(record[k])
+		}
+
+		if len(sqlBinds) > 0 && (len(sqlValues)+len(group.columns) > maxPlaceholders || size+rowSize > maxBytes) {
+			flush(i)
+		}
+		if len(sqlBinds) == 0 {
+			first, size = group.first+i, len(prefix)+len(suffix)
+		}
+
+		sqlValuesBind := make([]string, len(group.columns))
+		for j, k := range group.columns {
+			sqlValues = append(sqlValues, record[k])
+			sqlValuesBind[j] = l.bindVar(len(sqlValues))
+		}
+		sqlBinds = append(sqlBinds, fmt.Sprintf("(%s)", strings.Join(sqlValuesBind, ", ")))
+		size += rowSize
+	}
+	flush(len(group.records))
+
+	return inserts, nil
+}
+
+// valueSize estimates the bytes taken by v in the statement sent to the server
+func valueSize(v interface{}) int {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if dv, err := valuer.Value(); err == nil {
+			v = dv
+		}
+	}
+	switch v := v.(type) {
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	case time.Time:
+		return len(time.RFC3339Nano)
+	}
+	return 8
+}
+
+// bindVar returns the placeholder of the n-th (1-based) parameter of a query
+func (l *Loader) bindVar(n int) string {
+	if l.helper.paramType() == paramTypeDollar {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
 func (l *Loader) fixturesFromDir(dir string) ([]*fixtureFile, error) {
 	fileinfos, err := ioutil.ReadDir(dir)
 	if err != nil {
@@ -230,7 +794,7 @@ func (l *Loader) fixturesFromDir(dir string) ([]*fixtureFile, error) {
 
 	for _, fileinfo := range fileinfos {
 		fileExt := filepath.Ext(fileinfo.Name())
-		if !fileinfo.IsDir() && (fileExt == ".yml" || fileExt == ".yaml") {
+		if !fileinfo.IsDir() && (fileExt == ".yml" || fileExt == ".yaml" || fileExt == ".json" || fileExt == ".csv") {
 			files = append(files, path.Join(dir, fileinfo.Name()))
 		}
 	}
@@ -248,6 +812,14 @@ func (l *Loader) fixturesFromFiles(fileNames ...string) ([]*fixtureFile, error)
 			path:     f,
 			fileName: filepath.Base(f),
 		}
+		// CSV files are streamed when loading
+		if fixture.isCSV() {
+			if _, err = os.Stat(fixture.path); err != nil {
+				return nil, fmt.Errorf(`testfixtures: could not read file "%s": %w`, fixture.path, err)
+			}
+			fixtureFiles = append(fixtureFiles, fixture)
+			continue
+		}
 		fixture.content, err = ioutil.ReadFile(fixture.path)
 		if err != nil {
 			return nil, fmt.Errorf(`testfixtures: could not read file "%s": %w`, fixture.path, err)
@@ -261,3 +833,21 @@ func (l *Loader) fixturesFromFiles(fileNames ...string) ([]*fixtureFile, error)
 
 	return fixtureFiles, nil
 }
+
+func containsSection(sections []*fixtureSection, s *fixtureSection) bool {
+	for _, v := range sections {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -73,3 +74,118 @@ func TestLoader_Load(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, `test@test.cn`, content)
 }
+
+func TestLoader_buildInsertSQL_batches(t *testing.T) {
+	group := recordGroup{
+		first:   2,
+		columns: []string{"id", "name"},
+		records: []map[string]interface{}{
+			{"id": 1, "name": "alice"},
+			{"id": 2, "name": "bob"},
+			{"id": 3, "name": "carol"},
+		},
+	}
+
+	l := &Loader{helper: &postgreSQL{}}
+	inserts, err := l.buildInsertSQL(StrategyInsert, "users", group)
+	require.NoError(t, err)
+	require.Len(t, inserts, 1)
+	assert.Equal(t, `INSERT INTO "users"("id", "name") VALUES ($1, $2), ($3, $4), ($5, $6)`, inserts[0].sql)
+
+	l.batchPlaceholders = 4
+	inserts, err = l.buildInsertSQL(StrategyInsert, "users", group)
+	require.NoError(t, err)
+	require.Len(t, inserts, 2)
+	assert.Equal(t, `INSERT INTO "users"("id", "name") VALUES ($1, $2), ($3, $4)`, inserts[0].sql)
+	assert.Equal(t, 2, inserts[0].first)
+	assert.Equal(t, `INSERT INTO "users"("id", "name") VALUES ($1, $2)`, inserts[1].sql)
+	assert.Equal(t, []interface{}{3, "carol"}, inserts[1].params)
+	assert.Equal(t, 4, inserts[1].first)
+	assert.Equal(t, group.records[2:], inserts[1].records)
+
+	l.batchPlaceholders, l.batchBytes = 0, 1
+	inserts, err = l.buildInsertSQL(StrategyInsert, "users", group)
+	require.NoError(t, err)
+	assert.Len(t, inserts, 3)
+}
+
+func TestBatchSize(t *testing.T) {
+	_, err := New(BatchSize(-1, 0))
+	assert.Error(t, err)
+}
+
+func TestLoader_insertClauses(t *testing.T) {
+	columns := []string{"id", "name"}
+	tests := []struct {
+		helper   helper
+		strategy InsertStrategy
+		sql      string
+	}{
+		{&mySQL{}, StrategyInsert, "INSERT INTO `users`(`id`, `name`) VALUES (?, ?)"},
+		{&mySQL{}, StrategyInsertIgnore, "INSERT IGNORE INTO `users`(`id`, `name`) VALUES (?, ?)"},
+		{&mySQL{}, StrategyUpsert, "INSERT INTO `users`(`id`, `name`) VALUES (?, ?) ON DUPLICATE KEY UPDATE `id` = VALUES(`id`), `name` = VALUES(`name`)"},
+		{&mySQL{}, StrategyReplace, "REPLACE INTO `users`(`id`, `name`) VALUES (?, ?)"},
+		{&sqlite{}, StrategyInsertIgnore, `INSERT OR IGNORE INTO "users"("id", "name") VALUES (?, ?)`},
+		{&sqlite{}, StrategyUpsert, `INSERT INTO "users"("id", "name") VALUES (?, ?) ON CONFLICT DO UPDATE SET "id" = excluded."id", "name" = excluded."name"`},
+		{&postgreSQL{}, StrategyInsertIgnore, `INSERT INTO "users"("id", "name") VALUES ($1, $2) ON CONFLICT DO NOTHING`},
+		{&postgreSQL{primaryKeys: map[string][]string{"users": {"id"}}}, StrategyUpsert, `INSERT INTO "users"("id", "name") VALUES ($1, $2) ON CONFLICT ("id") DO UPDATE SET "name" = EXCLUDED."name"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy.String(), func(t *testing.T) {
+			l := &Loader{helper: tt.helper}
+			inserts, err := l.buildInsertSQL(tt.strategy, "users", recordGroup{
+				columns: columns,
+				records: []map[string]interface{}{{"id": 1, "name": "alice"}},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.sql, inserts[0].sql)
+		})
+	}
+
+	_, _, err := (&postgreSQL{}).insertClauses(StrategyReplace, "users", columns)
+	assert.Error(t, err)
+	_, _, err = (&postgreSQL{}).insertClauses(StrategyUpsert, "users", columns)
+	assert.Error(t, err)
+}
+
+func TestSQLMode(t *testing.T) {
+	_, err := New(Database(openSQLite(t)), Dialect("sqlite3"), SQLMode(SQLModeStrict))
+	assert.EqualError(t, err, "testfixtures: SQLMode is only supported by MySQL")
+}
+
+func TestLoader_Load_sqlMode(t *testing.T) {
+	db, err := sql.Open("mysql", testDSN)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	_, err = db.Exec("DROP DATABASE IF EXISTS testfixtures_sql_mode")
+	require.NoError(t, err)
+	_, err = db.Exec("CREATE DATABASE testfixtures_sql_mode")
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_, _ = db.Exec("DROP DATABASE IF EXISTS testfixtures_sql_mode")
+	})
+
+	db2, err := sql.Open("mysql", testDSN+"testfixtures_sql_mode")
+	require.NoError(t, err)
+	// closed before the database is dropped, the cleanups run last in first out
+	t.Cleanup(func() { _ = db2.Close() })
+	db2.SetMaxOpenConns(1)
+	_, err = db2.Exec("CREATE TABLE roles (id int NOT NULL PRIMARY KEY, name varchar(5) NOT NULL)")
+	require.NoError(t, err)
+	file := writeFixture(t, t.TempDir(), "roles.yml", "- id: 1\n  name: administrator\n")
+
+	f, err := New(Database(db2), Files(file), SQLMode(SQLModeStrict))
+	require.NoError(t, err)
+	assert.Error(t, f.Load())
+
+	f, err = New(Database(db2), Files(file), SQLMode(""))
+	require.NoError(t, err)
+	require.NoError(t, f.Load())
+	var name string
+	require.NoError(t, db2.QueryRow("SELECT name FROM roles WHERE id = 1").Scan(&name))
+	assert.Equal(t, "admin", name)
+
+	var mode string
+	require.NoError(t, db2.QueryRow("SELECT @@SESSION.sql_mode").Scan(&mode))
+	assert.NotEmpty(t, mode)
+}
@@ -0,0 +1,270 @@
+package fixtures
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// column describes a column of a table, as read by Validate
+type column struct {
+	name string
+	// dataType is the lower case type name without its length, like varchar
+	dataType string
+	unsigned bool
+	nullable bool
+	// hasDefault is also set for the values generated by the database, like
+	// auto increment ids
+	hasDefault bool
+	// length is the most characters of a string column, 0 when unbounded
+	length int64
+	enum   []string
+}
+
+// intBits are the sizes of the integer types
+var intBits = map[string]uint{
+	"tinyint":     8,
+	"smallint":    16,
+	"int2":        16,
+	"smallserial": 16,
+	"mediumint":   24,
+	"int":         32,
+	"integer":     32,
+	"int4":        32,
+	"serial":      32,
+	"bigint":      64,
+	"int8":        64,
+	"bigserial":   64,
+}
+
+// ValidationIssue is a fixture value that does not fit the schema
+type ValidationIssue struct {
+	File  string
+	Table string
+	// Record is the index of the record in the table of the file, -1 for
+	// an issue of the whole table
+	Record  int
+	Column  string
+	Message string
+}
+
+func (i ValidationIssue) String() string {
+	s := fmt.Sprintf("file: %s, table: %s", i.File, i.Table)
+	if i.Record >= 0 {
+		s += fmt.Sprintf(", record: %d", i.Record)
+	}
+	if i.Column != "" {
+		s += ", column: " + i.Column
+	}
+	return s + ": " + i.Message
+}
+
+// ValidationError lists every issue found by Validate
+type ValidationError struct {
+	Issues []ValidationIssue
+}
+
+func (e *ValidationError) Error() string {
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = "\n\t" + issue.String()
+	}
+	return fmt.Sprintf("testfixtures: %d invalid fixture values:%s", len(e.Issues), strings.Join(lines, ""))
+}
+
+// Validate checks the fixtures against the columns of the database without
+// loading them. It returns a ValidationError reporting at once the unknown
+// tables and columns, the missing NOT NULL columns without default, the
+// values that don't fit the type or the length of their column, the
+// invalid enum values and the unparseable dates.
+//
+//	if err := fixtures.Validate(); err != nil {
+//		...
+//	}
+func (l *Loader) Validate() error {
+	var (
+		issues []ValidationIssue
+		tables = make(map[string][]column)
+	)
+	for _, s := range l.sections {
+		columns, ok := tables[s.table]
+		if !ok {
+			var err error
+			if columns, err = l.helper.columns(l.db, s.table); err != nil {
+				return fmt.Errorf("testfixtures: could not read the columns of %s: %w", s.table, err)
+			}
+			tables[s.table] = columns
+		}
+		if len(columns) == 0 {
+			issues = append(issues, ValidationIssue{File: s.file.fileName, Table: s.table, Record: -1, Message: "unknown table"})
+			continue
+		}
+
+		byName := make(map[string]*column, len(columns))
+		for i := range columns {
+			byName[columns[i].name] = &columns[i]
+		}
+		for _, insert := range s.inserts {
+			for i, record := range insert.records {
+				issue := func(column, format string, args ...interface{}) {
+					issues = append(issues, ValidationIssue{
+						File:    s.file.fileName,
+						Table:   s.table,
+						Record:  insert.first + i,
+						Column:  column,
+						Message: fmt.Sprintf(format, args...),
+					})
+				}
+				for _, name := range insert.columns {
+					c, ok := byName[name]
+					if !ok {
+						issue(name, "unknown column")
+						continue
+					}
+					if msg := l.checkValue(c, record[name]); msg != "" {
+						issue(name, "%s", msg)
+					}
+				}
+				for _, c := range columns {
+					if !c.nullable && !c.hasDefault && !containsString(insert.columns, c.name) {
+						issue(c.name, "missing value of NOT NULL column without default")
+					}
+				}
+			}
+		}
+	}
+
+	if len(issues) > 0 {
+		return &ValidationError{Issues: issues}
+	}
+	return nil
+}
+
+// checkValue describes why v does not fit column c, it returns "" when it does
+func (l *Loader) checkValue(c *column, v interface{}) string {
+	if v == nil {
+		if !c.nullable {
+			return "NULL in NOT NULL column"
+		}
+		return ""
+	}
+	if _, ok := v.(reference); ok {
+		return ""
+	}
+
+	switch dt := c.dataType; {
+	case intBits[dt] > 0:
+		return checkInteger(c, v)
+	case dt == "decimal" || dt == "numeric" || dt == "float" || dt == "double" ||
+		dt == "real" || dt == "double precision":
+		switch v := v.(type) {
+		case int, int64, uint64, float64:
+		case string:
+			if _, err := strconv.ParseFloat(v, 64); err != nil {
+				return fmt.Sprintf("%q is not a number", v)
+			}
+		default:
+			return fmt.Sprintf("%v is not a number", v)
+		}
+	case dt == "enum":
+		if !containsString(c.enum, fmt.Sprint(v)) {
+			return fmt.Sprintf("%q is not one of %s", fmt.Sprint(v), strings.Join(c.enum, ", "))
+		}
+	case dt == "date" || dt == "datetime" || strings.HasPrefix(dt, "timestamp"):
+		// the parseable strings are converted to time.Time when the file is read
+		if s, ok := v.(string); ok {
+			if _, err := tryStrToDate(l.location, s); err != nil {
+				return fmt.Sprintf("%q is not a date", s)
+			}
+		}
+	case strings.Contains(dt, "char") || strings.Contains(dt, "text") || dt == "clob":
+		if s, ok := v.(string); ok && c.length > 0 && int64(utf8.RuneCountInString(s)) > c.length {
+			return fmt.Sprintf("%d characters are longer than %s(%d)", utf8.RuneCountInString(s), dt, c.length)
+		}
+	case dt == "bool" || dt == "boolean":
+		switch v := v.(type) {
+		case bool:
+		case int:
+			if v != 0 && v != 1 {
+				return fmt.Sprintf("%d is not a boolean", v)
+			}
+		default:
+			return fmt.Sprintf("%v is not a boolean", v)
+		}
+	}
+	return ""
+}
+
+// checkInteger checks that v is an integer within the range of column c
+func checkInteger(c *column, v interface{}) string {
+	var (
+		n        float64
+		bits     = intBits[c.dataType]
+		min, max = -math.Pow(2, float64(bits-1)), math.Pow(2, float64(bits-1)) - 1
+	)
+	if c.unsigned {
+		min, max = 0, math.Pow(2, float64(bits))-1
+	}
+	switch v := v.(type) {
+	case bool:
+		return ""
+	case int:
+		n = float64(v)
+	case int64:
+		n = float64(v)
+	case uint64:
+		n = float64(v)
+	case float64:
+		if v != math.Trunc(v) {
+			return fmt.Sprintf("%v is not an integer", v)
+		}
+		n = v
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Sprintf("%q is not an integer", v)
+		}
+		n = float64(i)
+	case time.Time:
+		return fmt.Sprintf("date %s is not an integer", v.Format(time.RFC3339))
+	default:
+		return fmt.Sprintf("%v is not an integer", v)
+	}
+	if n < min || n > max {
+		return fmt.Sprintf("%v is out of the range of %s", v, c.dataType)
+	}
+	return ""
+}
+
+// parseColumnType splits a declared type like "varchar(50)" or "int(11)
+// unsigned" into its lower case name, length and sign
+func parseColumnType(declared string) (dataType string, length int64, unsigned bool) {
+	declared = strings.ToLower(strings.TrimSpace(declared))
+	unsigned = strings.Contains(declared, "unsigned")
+	dataType = declared
+	if i := strings.IndexByte(declared, '('); i >= 0 {
+		dataType = declared[:i]
+		if j := strings.IndexByte(declared[i:], ')'); j > 0 {
+			size := strings.SplitN(declared[i+1:i+j], ",", 2)[0]
+			length, _ = strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		}
+	}
+	dataType = strings.TrimSpace(strings.NewReplacer("unsigned", "", "zerofill", "").Replace(dataType))
+	return dataType, length, unsigned
+}
+
+// enumRegexp matches the quoted values of enum('a','b')
+var enumRegexp = regexp.MustCompile(`'((?:[^']|'')*)'`)
+
+// parseEnum returns the values of a MySQL column type like enum('a','b')
+func parseEnum(columnType string) []string {
+	var values []string
+	for _, m := range enumRegexp.FindAllStringSubmatch(columnType, -1) {
+		values = append(values, strings.ReplaceAll(m[1], "''", "'"))
+	}
+	return values
+}
@@ -0,0 +1,56 @@
+package fixtures
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseColumnType(t *testing.T) {
+	for declared, want := range map[string]column{
+		"VARCHAR(50)":         {dataType: "varchar", length: 50},
+		"int(11) unsigned":    {dataType: "int", length: 11, unsigned: true},
+		"decimal(10, 2)":      {dataType: "decimal", length: 10},
+		"TEXT":                {dataType: "text"},
+		"character varying":   {dataType: "character varying"},
+		"tinyint(3) zerofill": {dataType: "tinyint", length: 3},
+	} {
+		dataType, length, unsigned := parseColumnType(declared)
+		assert.Equal(t, want, column{dataType: dataType, length: length, unsigned: unsigned}, declared)
+	}
+}
+
+func Test_parseEnum(t *testing.T) {
+	assert.Equal(t, []string{"admin", "user", "it's"}, parseEnum("enum('admin','user','it''s')"))
+}
+
+func TestLoader_checkValue(t *testing.T) {
+	l := &Loader{}
+	for _, tt := range []struct {
+		column column
+		value  interface{}
+		want   string
+	}{
+		{column{dataType: "int"}, 12, ""},
+		{column{dataType: "int"}, "12", ""},
+		{column{dataType: "int"}, "twelve", `"twelve" is not an integer`},
+		{column{dataType: "int"}, 1.5, "1.5 is not an integer"},
+		{column{dataType: "tinyint"}, 128, "128 is out of the range of tinyint"},
+		{column{dataType: "tinyint", unsigned: true}, 255, ""},
+		{column{dataType: "int", unsigned: true}, -1, "-1 is out of the range of int"},
+		{column{dataType: "int", nullable: true}, nil, ""},
+		{column{dataType: "int"}, nil, "NULL in NOT NULL column"},
+		{column{dataType: "int"}, reference{table: "users", label: "alice"}, ""},
+		{column{dataType: "decimal"}, "1.25", ""},
+		{column{dataType: "decimal"}, "a", `"a" is not a number`},
+		{column{dataType: "varchar", length: 3}, "abc", ""},
+		{column{dataType: "varchar", length: 3}, "张三李四", "4 characters are longer than varchar(3)"},
+		{column{dataType: "enum", enum: []string{"admin", "user"}}, "root", `"root" is not one of admin, user`},
+		{column{dataType: "enum", enum: []string{"admin", "user"}}, "user", ""},
+		{column{dataType: "datetime"}, "2020-13-45", `"2020-13-45" is not a date`},
+		{column{dataType: "timestamp with time zone"}, "2020-01-02", ""},
+		{column{dataType: "boolean"}, 2, "2 is not a boolean"},
+	} {
+		assert.Equal(t, tt.want, l.checkValue(&tt.column, tt.value), "%s %v", tt.column.dataType, tt.value)
+	}
+}
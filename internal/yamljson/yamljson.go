@@ -0,0 +1,73 @@
+// Package yamljson decodes the JSON fixture files into the values of the
+// same YAML files, for the loader and PluckWithFixtureE alike.
+package yamljson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Decode decodes a JSON fixture file into the values yaml.v2 gives, maps
+// keyed by interface{} and whole numbers as int, and returns the keys of the
+// top level object in the order of the file
+func Decode(content []byte) (interface{}, []interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err == io.EOF {
+		return nil, nil, nil
+	} else if err != nil {
+		return nil, nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, nil, fmt.Errorf("unexpected data after the top level value")
+	}
+
+	var keys []interface{}
+	if _, ok := doc.(map[string]interface{}); ok {
+		dec = json.NewDecoder(bytes.NewReader(content))
+		if _, err := dec.Token(); err != nil {
+			return nil, nil, err
+		}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, nil, err
+			}
+			var value json.RawMessage
+			if err := dec.Decode(&value); err != nil {
+				return nil, nil, err
+			}
+			keys = append(keys, key)
+		}
+	}
+	return fromJSON(doc), keys, nil
+}
+
+func fromJSON(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i, e := range v {
+			v[i] = fromJSON(e)
+		}
+		return v
+	case map[string]interface{}:
+		m := make(map[interface{}]interface{}, len(v))
+		for k, e := range v {
+			m[k] = fromJSON(e)
+		}
+		return m
+	case json.Number:
+		if i, err := strconv.ParseInt(string(v), 10, 0); err == nil {
+			return int(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return string(v)
+	}
+	return v
+}
@@ -0,0 +1,30 @@
+package yamljson
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecode(t *testing.T) {
+	doc, keys, err := Decode([]byte(`{"users": [{"id": 1, "score": 1.5, "tags": ["a"], "profile": {"age": 3}}], "roles": []}`))
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"users", "roles"}, keys)
+	assert.Equal(t, map[interface{}]interface{}{
+		"users": []interface{}{map[interface{}]interface{}{
+			"id":      1,
+			"score":   1.5,
+			"tags":    []interface{}{"a"},
+			"profile": map[interface{}]interface{}{"age": 3},
+		}},
+		"roles": []interface{}{},
+	}, doc)
+
+	doc, keys, err = Decode([]byte("  "))
+	assert.NoError(t, err)
+	assert.Nil(t, doc)
+	assert.Nil(t, keys)
+
+	_, _, err = Decode([]byte(`[] []`))
+	assert.Error(t, err)
+}
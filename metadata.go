@@ -0,0 +1,103 @@
+package dbunit
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metadataTable is created in every test database and records who created it
+const metadataTable = "dbunit_metadata"
+
+type metadata struct {
+	TestName  string
+	Package   string
+	PID       int
+	Hostname  string
+	StartedAt time.Time
+}
+
+func newMetadata(testName string) metadata {
+	hostname, _ := os.Hostname()
+	return metadata{
+		TestName:  testName,
+		Package:   packagePath(),
+		PID:       os.Getpid(),
+		Hostname:  hostname,
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// writeMetadata creates the metadata table in the database db is connected to
+func writeMetadata(db *sql.DB, d dialect, m metadata) error {
+	_, err := db.Exec(fmt.Sprintf(`CREATE TABLE %s (
+		test_name VARCHAR(255) NOT NULL,
+		package_name VARCHAR(255) NOT NULL,
+		pid INTEGER NOT NULL,
+		hostname VARCHAR(255) NOT NULL,
+		started_at TIMESTAMP NOT NULL
+	)`, d.quote(metadataTable)))
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (test_name, package_name, pid, hostname, started_at) VALUES (%s, %s, %s, %s, %s)",
+		d.quote(metadataTable), d.placeholder(1), d.placeholder(2), d.placeholder(3), d.placeholder(4), d.placeholder(5))
+	_, err = db.Exec(query, m.TestName, m.Package, m.PID, m.Hostname, m.StartedAt)
+	return err
+}
+
+var (
+	packageOnce sync.Once
+	packageName string
+)
+
+// packagePath returns the import path of the package under test, go test
+// runs the test binary in the directory of the package
+func packagePath() string {
+	packageOnce.Do(func() {
+		packageName = strings.TrimSuffix(filepath.Base(os.Args[0]), ".test")
+
+		wd, err := os.Getwd()
+		if err != nil {
+			return
+		}
+		for dir := wd; ; dir = filepath.Dir(dir) {
+			if module := modulePath(filepath.Join(dir, "go.mod")); module != "" {
+				rel, err := filepath.Rel(dir, wd)
+				if err == nil {
+					packageName = path.Join(module, filepath.ToSlash(rel))
+				}
+				return
+			}
+			if filepath.Dir(dir) == dir {
+				return
+			}
+		}
+	})
+	return packageName
+}
+
+// modulePath reads the module directive of a go.mod file
+func modulePath(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
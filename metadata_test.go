@@ -0,0 +1,39 @@
+package dbunit
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_packagePath(t *testing.T) {
+	assert.Equal(t, "github.com/goapt/dbunit", packagePath())
+}
+
+func TestMetadata(t *testing.T) {
+	useSQLite(t, t.TempDir())
+
+	New(t, func(d *DBUnit) {
+		test := d.NewTesting("testdata/sqlite/schema.sql", "testdata/fixtures/users.yml")
+		assert.True(t, strings.HasPrefix(test.tdb.Name, "test_testmetadata_"), test.tdb.Name)
+
+		var (
+			m         metadata
+			startedAt time.Time
+		)
+		err := test.DB().QueryRow("SELECT test_name, package_name, pid, hostname, started_at FROM dbunit_metadata").
+			Scan(&m.TestName, &m.Package, &m.PID, &m.Hostname, &startedAt)
+		require.NoError(t, err)
+
+		hostname, _ := os.Hostname()
+		assert.Equal(t, "TestMetadata", m.TestName)
+		assert.Equal(t, "github.com/goapt/dbunit", m.Package)
+		assert.Equal(t, os.Getpid(), m.PID)
+		assert.Equal(t, hostname, m.Hostname)
+		assert.WithinDuration(t, time.Now(), startedAt, time.Minute)
+	})
+}
@@ -0,0 +1,269 @@
+package dbunit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+type mysqlDialect struct{}
+
+func (*mysqlDialect) dsn(source, name string, params url.Values) string {
+	if len(params) == 0 {
+		return source + name
+	}
+	return source + name + "?" + params.Encode()
+}
+
+func (*mysqlDialect) params(c Config) url.Values {
+	params := url.Values{}
+	params.Set("charset", "utf8mb4")
+	params.Set("parseTime", "True")
+	params.Set("loc", "Local")
+	if c.TimeZone != "" {
+		params.Set("loc", c.TimeZone)
+	}
+	if c.Collation != "" {
+		params.Set("collation", c.Collation)
+	}
+	if c.SQLMode != "" {
+		// unknown parameters are system variables, strings must be quoted
+		params.Set("sql_mode", "'"+c.SQLMode+"'")
+	}
+	return params
+}
+
+func (*mysqlDialect) serverDSN(source, _ string) string {
+	return source
+}
+
+func (*mysqlDialect) createDatabase(db *sql.DB, name string) error {
+	query := fmt.Sprintf("CREATE DATABASE IF NOT EXISTS %s", name)
+	defaultLog.Print(query)
+	_, err := db.Exec(query)
+	return err
+}
+
+func (*mysqlDialect) dropDatabase(db *sql.DB, name string) error {
+	query := fmt.Sprintf("DROP DATABASE IF EXISTS %s", name)
+	defaultLog.Print(query)
+	_, err := db.Exec(query)
+	return err
+}
+
+// cloneDatabase recreates the tables of template with SHOW CREATE TABLE,
+// unlike CREATE TABLE ... LIKE it keeps the foreign keys, and copies the
+// rows seeded by the schema. Views, triggers, routines and events are not
+// cloned, a template holding any of them is reported as unsupported.
+func (d *mysqlDialect) cloneDatabase(db *sql.DB, _ Config, template, name string) error {
+	objects, err := d.countObjects(db, template)
+	if err != nil {
+		return err
+	}
+	if objects > 0 {
+		return errCloneUnsupported
+	}
+
+	// generated columns can not be written, they are left out of the copy
+	rows, err := db.Query(`
+		SELECT c.TABLE_NAME, c.COLUMN_NAME
+		FROM information_schema.COLUMNS c
+		JOIN information_schema.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+		WHERE c.TABLE_SCHEMA = ?
+		  AND t.TABLE_TYPE = 'BASE TABLE'
+		  AND c.EXTRA NOT LIKE '%GENERATED%'
+		ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION
+	`, template)
+	if err != nil {
+		return err
+	}
+	var (
+		tables  []string
+		columns = make(map[string][]string)
+	)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return err
+		}
+		if _, ok := columns[table]; !ok {
+			tables = append(tables, table)
+		}
+		columns[table] = append(columns[table], d.quote(column))
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if err := d.createDatabase(db, name); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "USE "+d.quote(name)); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 0"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "SET FOREIGN_KEY_CHECKS = 1")
+
+	for _, table := range tables {
+		var tableName, ddl string
+		query := fmt.Sprintf("SHOW CREATE TABLE %s.%s", d.quote(template), d.quote(table))
+		if err := conn.QueryRowContext(ctx, query).Scan(&tableName, &ddl); err != nil {
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, ddl); err != nil {
+			return err
+		}
+
+		cols := strings.Join(columns[table], ", ")
+		query = fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s.%s", d.quote(table), cols, cols, d.quote(template), d.quote(table))
+		if _, err := conn.ExecContext(ctx, query); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// countObjects counts the views, triggers, routines and events of database name
+func (*mysqlDialect) countObjects(db *sql.DB, name string) (int, error) {
+	var objects int
+	err := db.QueryRow(`
+		SELECT (SELECT COUNT(*) FROM information_schema.VIEWS WHERE TABLE_SCHEMA = ?)
+		     + (SELECT COUNT(*) FROM information_schema.TRIGGERS WHERE TRIGGER_SCHEMA = ?)
+		     + (SELECT COUNT(*) FROM information_schema.ROUTINES WHERE ROUTINE_SCHEMA = ?)
+		     + (SELECT COUNT(*) FROM information_schema.EVENTS WHERE EVENT_SCHEMA = ?)
+	`, name, name, name, name).Scan(&objects)
+	return objects, err
+}
+
+// renameDatabase moves the tables to a new database, MySQL can not rename a
+// database. Views can not be moved and RENAME TABLE refuses tables having
+// triggers, such databases keep their name.
+func (d *mysqlDialect) renameDatabase(db *sql.DB, _ Config, name, newName string) error {
+	objects, err := d.countObjects(db, name)
+	if err != nil {
+		return err
+	}
+	if objects > 0 {
+		return fmt.Errorf("dbunit: database %s has views, triggers, routines or events and can not be renamed", name)
+	}
+
+	tables, err := queryStrings(db, "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = ?", name)
+	if err != nil {
+		return err
+	}
+	// no IF NOT EXISTS, the tables of another database must not be mixed in
+	// and it must not be dropped when the tables can not be moved
+	query := fmt.Sprintf("CREATE DATABASE %s", newName)
+	defaultLog.Print(query)
+	if _, err := db.Exec(query); err != nil {
+		return err
+	}
+	if len(tables) > 0 {
+		renames := make([]string, len(tables))
+		for i, table := range tables {
+			renames[i] = fmt.Sprintf("%s.%s TO %s.%s", d.quote(name), d.quote(table), d.quote(newName), d.quote(table))
+		}
+		// a single RENAME TABLE is atomic
+		if _, err := db.Exec("RENAME TABLE " + strings.Join(renames, ", ")); err != nil {
+			_ = d.dropDatabase(db, newName)
+			return err
+		}
+	}
+	return d.dropDatabase(db, name)
+}
+
+// clientCommand returns the mysql command line connecting to database name,
+// the password is asked for
+func (*mysqlDialect) clientCommand(source, name string) string {
+	cfg, err := mysql.ParseDSN(source)
+	if err != nil {
+		return "mysql " + name
+	}
+	cmd := "mysql"
+	if cfg.Net == "unix" {
+		cmd += " -S " + cfg.Addr
+	} else if host, port, err := net.SplitHostPort(cfg.Addr); err == nil {
+		cmd += " -h " + host + " -P " + port
+	}
+	if cfg.User != "" {
+		cmd += " -u " + cfg.User
+	}
+	if cfg.Passwd != "" {
+		cmd += " -p"
+	}
+	return cmd + " " + name
+}
+
+func (*mysqlDialect) listDatabases(db *sql.DB, _ string) ([]string, error) {
+	return queryStrings(db, `SELECT SCHEMA_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME LIKE 'test\\_%' ORDER BY SCHEMA_NAME`)
+}
+
+func (*mysqlDialect) quote(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+func (*mysqlDialect) tableNames(db *sql.DB) ([]string, error) {
+	return queryStrings(db, "SELECT TABLE_NAME FROM information_schema.TABLES WHERE TABLE_SCHEMA = DATABASE() AND TABLE_TYPE = 'BASE TABLE' ORDER BY TABLE_NAME")
+}
+
+func (*mysqlDialect) placeholder(int) string {
+	return "?"
+}
+
+// disableForeignKeys sets a session variable, it outlives the transaction
+// so enableForeignKeys resets it before the connection goes back to the pool
+func (*mysqlDialect) disableForeignKeys(tx *sql.Tx) error {
+	_, err := tx.Exec("SET FOREIGN_KEY_CHECKS = 0")
+	return err
+}
+
+func (*mysqlDialect) enableForeignKeys(tx *sql.Tx) error {
+	_, err := tx.Exec("SET FOREIGN_KEY_CHECKS = 1")
+	return err
+}
+
+func (d *mysqlDialect) checksum(db *sql.DB, tables []string) (map[string]string, error) {
+	quoted := make([]string, len(tables))
+	for i, table := range tables {
+		quoted[i] = d.quote(table)
+	}
+	rows, err := db.Query("CHECKSUM TABLE " + strings.Join(quoted, ", "))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sums := make(map[string]string, len(tables))
+	for rows.Next() {
+		var (
+			table string
+			sum   sql.NullString
+		)
+		if err := rows.Scan(&table, &sum); err != nil {
+			return nil, err
+		}
+		// tables are reported as database.table
+		if i := strings.IndexByte(table, '.'); i >= 0 {
+			table = table[i+1:]
+		}
+		sums[table] = sum.String
+	}
+	return sums, rows.Err()
+}
@@ -0,0 +1,167 @@
+package dbunit
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// databaseNameRegex matches the names given by newDatabaseName, the first
+// number is the creation time in nanoseconds
+var databaseNameRegex = regexp.MustCompile(`^test_(?:[a-z0-9_]+_)?(\d{19})_\d+(_tpl)?$`)
+
+// failedNameRegex matches the names given by failedDatabaseName
+var failedNameRegex = regexp.MustCompile(`^test_failed_[a-z0-9_]+_(\d{14})_\d+_\d+$`)
+
+// TestDatabase 服务器上由 dbunit 创建的测试库
+type TestDatabase struct {
+	Name string
+	// Created 测试库的创建时间，从库名中解析
+	Created time.Time
+	// Template 是否为 schema 模板库
+	Template bool
+	// Failed 是否为保留下来的失败测试的测试库
+	Failed bool
+}
+
+// parseDatabaseName recognizes the databases created by dbunit
+func parseDatabaseName(name string) (TestDatabase, bool) {
+	if m := failedNameRegex.FindStringSubmatch(name); m != nil {
+		created, err := time.Parse("20060102150405", m[1])
+		if err != nil {
+			return TestDatabase{}, false
+		}
+		return TestDatabase{Name: name, Created: created, Failed: true}, true
+	}
+
+	m := databaseNameRegex.FindStringSubmatch(name)
+	if m == nil {
+		return TestDatabase{}, false
+	}
+	nanos, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return TestDatabase{}, false
+	}
+	return TestDatabase{
+		Name:     name,
+		Created:  time.Unix(0, nanos),
+		Template: m[2] != "",
+	}, true
+}
+
+// ListDatabases 列出 c 指向的服务器上由 dbunit 创建的测试库，c 中未设置的字段沿用默认配置
+func ListDatabases(c Config) ([]TestDatabase, error) {
+	return listDatabases(c.merge(defaultConfig))
+}
+
+func listDatabases(config Config) ([]TestDatabase, error) {
+	dialect, err := dialectFor(config.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open(config.Driver, dialect.serverDSN(config.DSN, ""))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	names, err := dialect.listDatabases(db, config.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("dbunit: list databases: %w", err)
+	}
+
+	var databases []TestDatabase
+	for _, name := range names {
+		if tdb, ok := parseDatabaseName(name); ok {
+			databases = append(databases, tdb)
+		}
+	}
+	return databases, nil
+}
+
+// Reap 删除 c 指向的服务器上创建时间早于 olderThan 之前的测试库，返回被删除的库名。
+// 只根据库名中的创建时间判断，olderThan 应大于最长的测试运行时间，以免删除其他进程正在使用的测试库
+func Reap(c Config, olderThan time.Duration) ([]string, error) {
+	return reap(c.merge(defaultConfig), olderThan)
+}
+
+func reap(config Config, olderThan time.Duration) ([]string, error) {
+	databases, err := expiredDatabases(config, olderThan)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dropped []string
+		errs    []error
+	)
+	for _, tdb := range databases {
+		if err := dropDatabase(config, tdb.Name); err != nil {
+			errs = append(errs, fmt.Errorf("dbunit: drop database %s: %w", tdb.Name, err))
+			continue
+		}
+		dropped = append(dropped, tdb.Name)
+	}
+	return dropped, errors.Join(errs...)
+}
+
+// ExpiredDatabases 列出 Reap 会删除的测试库，即创建时间早于 olderThan 之前的测试库
+func ExpiredDatabases(c Config, olderThan time.Duration) ([]TestDatabase, error) {
+	return expiredDatabases(c.merge(defaultConfig), olderThan)
+}
+
+func expiredDatabases(config Config, olderThan time.Duration) ([]TestDatabase, error) {
+	databases, err := listDatabases(config)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		expired []TestDatabase
+		before  = time.Now().Add(-olderThan)
+	)
+	for _, tdb := range databases {
+		if tdb.Created.Before(before) {
+			expired = append(expired, tdb)
+		}
+	}
+	return expired, nil
+}
+
+func dropDatabase(config Config, name string) error {
+	dialect, err := dialectFor(config.Driver)
+	if err != nil {
+		return err
+	}
+	tdb := &database{Name: name, config: config, dialect: dialect}
+	if err := tdb.connection(); err != nil {
+		return err
+	}
+	return tdb.Drop()
+}
+
+// sweeps runs the automatic reaping once per server and process
+var sweeps sync.Map
+
+// sweep reaps the databases older than config.ReapAfter before the first
+// database of the process is created on the server, failures are only logged
+func sweep(config Config) {
+	if config.ReapAfter <= 0 {
+		return
+	}
+	once, _ := sweeps.LoadOrStore(config.Driver+"|"+config.DSN, &sync.Once{})
+	once.(*sync.Once).Do(func() {
+		dropped, err := reap(config, config.ReapAfter)
+		if len(dropped) > 0 {
+			defaultLog.Print(fmt.Sprintf("Reaped %d orphaned test databases", len(dropped)))
+		}
+		if err != nil {
+			defaultLog.Print(fmt.Sprintf("Reap orphaned test databases error:%v", err))
+		}
+	})
+}
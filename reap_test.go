@@ -0,0 +1,76 @@
+package dbunit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseDatabaseName(t *testing.T) {
+	tdb, ok := parseDatabaseName("test_1713945600123456789_7")
+	require.True(t, ok)
+	assert.Equal(t, time.Unix(0, 1713945600123456789), tdb.Created)
+	assert.False(t, tdb.Template)
+
+	tdb, ok = parseDatabaseName("test_1713945600123456789_8_tpl")
+	require.True(t, ok)
+	assert.True(t, tdb.Template)
+
+	tdb, ok = parseDatabaseName("test_failed_testorder_create_20240424083000_4242_3")
+	require.True(t, ok)
+	assert.True(t, tdb.Failed)
+	assert.Equal(t, time.Date(2024, 4, 24, 8, 30, 0, 0, time.UTC), tdb.Created)
+
+	for _, name := range []string{"test", "test_db", "test_1_2_3", "prod_1713945600123456789_7"} {
+		_, ok := parseDatabaseName(name)
+		assert.False(t, ok, name)
+	}
+}
+
+// createSQLiteFile creates an empty database file named after created
+func createSQLiteFile(t *testing.T, dir string, created time.Time, id int) string {
+	name := fmt.Sprintf("test_%d_%d", created.UnixNano(), id)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, name+".db"), nil, 0644))
+	return name
+}
+
+func TestReap(t *testing.T) {
+	dir := t.TempDir()
+	useSQLite(t, dir)
+
+	old := createSQLiteFile(t, dir, time.Now().Add(-48*time.Hour), 1)
+	recent := createSQLiteFile(t, dir, time.Now(), 2)
+	template := fmt.Sprintf("test_%d_3_tpl", time.Now().Add(-48*time.Hour).UnixNano())
+	require.NoError(t, os.WriteFile(filepath.Join(dir, template+".db"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.db"), nil, 0644))
+
+	databases, err := ListDatabases(Config{})
+	require.NoError(t, err)
+	require.Len(t, databases, 3)
+
+	dropped, err := Reap(Config{}, 24*time.Hour)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{old, template}, dropped)
+
+	assert.NoFileExists(t, filepath.Join(dir, old+".db"))
+	assert.FileExists(t, filepath.Join(dir, recent+".db"))
+	assert.FileExists(t, filepath.Join(dir, "other.db"))
+}
+
+func TestReapAfter(t *testing.T) {
+	dir := t.TempDir()
+	useSQLite(t, dir)
+	old := createSQLiteFile(t, dir, time.Now().Add(-2*time.Hour), 1)
+
+	config := defaultConfig
+	config.ReapAfter = time.Hour
+	New(t, func(d *DBUnit) {
+		d.NewDatabaseWithConfig(config, "testdata/sqlite/schema.sql", "testdata/fixtures/users.yml")
+		assert.NoFileExists(t, filepath.Join(dir, old+".db"))
+	})
+}
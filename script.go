@@ -0,0 +1,222 @@
+package dbunit
+
+import (
+	"fmt"
+	"strings"
+)
+
+const defaultDelimiter = ";"
+
+// statement is a single SQL statement of a script file
+type statement struct {
+	query string
+	// line is the 1-based line of the script the statement starts on
+	line int
+}
+
+// ImportError will be returned if any statement of the schema file fails
+type ImportError struct {
+	Err  error
+	File string
+	Line int
+	SQL  string
+}
+
+func (e *ImportError) Error() string {
+	return fmt.Sprintf("dbunit: error importing schema: %v, on file: %s:%d, sql: %s", e.Err, e.File, e.Line, e.SQL)
+}
+
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// splitScript splits a SQL script into statements. It understands quoted
+// strings and identifiers, PostgreSQL dollar quoting, "#", "--" and "/* */"
+// comments, and the client side "DELIMITER $$" command used around stored
+// procedures and triggers, between statements only. Comments are stripped
+// from the statements and statements made of comments only are dropped.
+func splitScript(script string) ([]statement, error) {
+	var (
+		statements []statement
+		buf        strings.Builder
+		delimiter  = defaultDelimiter
+		line       = 1
+		start      = 0 // line of the first meaningful token of buf
+		lineStart  = true
+	)
+
+	flush := func() {
+		if start > 0 {
+			statements = append(statements, statement{query: strings.TrimSpace(buf.String()), line: start})
+		}
+		buf.Reset()
+		start = 0
+	}
+	// mark records that buf holds more than whitespace and comments
+	mark := func() {
+		if start == 0 {
+			start = line
+		}
+	}
+
+	for i := 0; i < len(script); {
+		c := script[i]
+
+		// a column named delimiter may start a line inside a statement
+		if lineStart && start == 0 {
+			if d, n, ok := parseDelimiterCommand(script[i:]); ok {
+				flush()
+				delimiter = d
+				line++
+				i += n
+				continue
+			}
+		}
+
+		if strings.HasPrefix(script[i:], delimiter) {
+			flush()
+			i += len(delimiter)
+			lineStart = false
+			continue
+		}
+
+		switch {
+		case c == '\n':
+			buf.WriteByte(c)
+			line++
+			i++
+			lineStart = true
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			buf.WriteByte(c)
+			i++
+			continue
+		case c == '#' || isDashComment(script, i):
+			end := strings.IndexByte(script[i:], '\n')
+			if end == -1 {
+				end = len(script) - i
+			}
+			i += end
+			continue
+		case strings.HasPrefix(script[i:], "/*"):
+			end := strings.Index(script[i+2:], "*/")
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated comment starting on line %d", line)
+			}
+			comment := script[i : i+2+end+2]
+			// MySQL executes the content of /*! ... */ comments and reads
+			// optimizer hints from /*+ ... */, everything else is dropped
+			if strings.HasPrefix(comment, "/*!") || strings.HasPrefix(comment, "/*+") {
+				mark()
+				buf.WriteString(comment)
+			} else {
+				buf.WriteByte(' ')
+			}
+			line += strings.Count(comment, "\n")
+			i += len(comment)
+		case c == '\'' || c == '"' || c == '`':
+			mark()
+			n, err := quotedLength(script[i:], c)
+			if err != nil {
+				return nil, fmt.Errorf("%w starting on line %d", err, line)
+			}
+			buf.WriteString(script[i : i+n])
+			line += strings.Count(script[i:i+n], "\n")
+			i += n
+		case c == '$' && (i == 0 || !isIdentByte(script[i-1])):
+			mark()
+			n := dollarQuotedLength(script[i:])
+			buf.WriteString(script[i : i+n])
+			line += strings.Count(script[i:i+n], "\n")
+			i += n
+		default:
+			mark()
+			buf.WriteByte(c)
+			i++
+		}
+		lineStart = false
+	}
+	flush()
+
+	return statements, nil
+}
+
+// parseDelimiterCommand recognizes a "DELIMITER xx" line and returns the new
+// delimiter and the length of the line including its line break
+func parseDelimiterCommand(s string) (string, int, bool) {
+	end := strings.IndexByte(s, '\n')
+	n := end + 1
+	if end == -1 {
+		end, n = len(s), len(s)
+	}
+	fields := strings.Fields(s[:end])
+	if len(fields) != 2 || !strings.EqualFold(fields[0], "DELIMITER") {
+		return "", 0, false
+	}
+	return fields[1], n, true
+}
+
+// isDashComment reports whether script has a "--" comment at i. MySQL wants
+// a space after the dashes, PostgreSQL doesn't, so "--" is also a comment
+// when it doesn't follow an operand, unlike in "1--1".
+func isDashComment(script string, i int) bool {
+	s := script[i:]
+	if !strings.HasPrefix(s, "--") {
+		return false
+	}
+	if len(s) == 2 || s[2] == ' ' || s[2] == '\t' || s[2] == '\r' || s[2] == '\n' {
+		return true
+	}
+	if i == 0 {
+		return true
+	}
+	switch script[i-1] {
+	case ' ', '\t', '\r', '\n', '(', ',', ';':
+		return true
+	}
+	return false
+}
+
+// quotedLength returns the length of the quoted string at the start of s,
+// quotes are escaped by doubling them or, except for identifiers, by a backslash
+func quotedLength(s string, quote byte) (int, error) {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if quote != '`' {
+				i++
+			}
+		case quote:
+			if i+1 < len(s) && s[i+1] == quote {
+				i++
+				continue
+			}
+			return i + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated %c quoted string", quote)
+}
+
+// dollarQuotedLength returns the length of the $tag$...$tag$ string at the
+// start of s, or 1 when s does not start with a dollar quote
+func dollarQuotedLength(s string) int {
+	end := strings.IndexByte(s[1:], '$')
+	if end == -1 {
+		return 1
+	}
+	tag := s[:end+2]
+	for i := 1; i < len(tag)-1; i++ {
+		if !isIdentByte(tag[i]) {
+			return 1
+		}
+	}
+	closing := strings.Index(s[len(tag):], tag)
+	if closing == -1 {
+		return 1
+	}
+	return len(tag) + closing + len(tag)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
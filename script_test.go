@@ -0,0 +1,130 @@
+package dbunit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func queries(statements []statement) []string {
+	s := make([]string, len(statements))
+	for i, stmt := range statements {
+		s[i] = stmt.query
+	}
+	return s
+}
+
+func Test_splitScript(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		want   []string
+	}{
+		{
+			name:   "simple",
+			script: "CREATE TABLE a (id int);\nINSERT INTO a VALUES (1);",
+			want:   []string{"CREATE TABLE a (id int)", "INSERT INTO a VALUES (1)"},
+		},
+		{
+			name:   "delimiter in strings",
+			script: "CREATE TABLE a (id int COMMENT 'a;b') COMMENT=\"x;y\";\nINSERT INTO `a;b` VALUES ('it''s;', 'c\\';d');",
+			want:   []string{"CREATE TABLE a (id int COMMENT 'a;b') COMMENT=\"x;y\"", "INSERT INTO `a;b` VALUES ('it''s;', 'c\\';d')"},
+		},
+		{
+			name:   "comments",
+			script: "# Dump; of table\n-- a; b\n/* c; d */\nCREATE TABLE a (id int); -- trailing\n/*!40101 SET NAMES utf8 */;\n--",
+			want:   []string{"CREATE TABLE a (id int)", "/*!40101 SET NAMES utf8 */"},
+		},
+		{
+			name:   "delimiter command",
+			script: "DELIMITER $$\nCREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND$$\ndelimiter ;\nCALL p();",
+			want:   []string{"CREATE PROCEDURE p()\nBEGIN\n  SELECT 1;\n  SELECT 2;\nEND", "CALL p()"},
+		},
+		{
+			name:   "delimiter column",
+			script: "CREATE TABLE a (\n  id int,\n  delimiter VARCHAR(10)\n);\nDELIMITER ;;\nSELECT 1;;",
+			want:   []string{"CREATE TABLE a (\n  id int,\n  delimiter VARCHAR(10)\n)", "SELECT 1"},
+		},
+		{
+			name:   "comments without space",
+			script: "--comment; here\nCREATE TABLE a (id int); --trailing\nSELECT 1--1;",
+			want:   []string{"CREATE TABLE a (id int)", "SELECT 1--1"},
+		},
+		{
+			name:   "dollar quoting",
+			script: "CREATE FUNCTION f() RETURNS trigger AS $body$ BEGIN NEW.a := 1; RETURN NEW; END; $body$ LANGUAGE plpgsql;\nSELECT $$a;b$$;",
+			want:   []string{"CREATE FUNCTION f() RETURNS trigger AS $body$ BEGIN NEW.a := 1; RETURN NEW; END; $body$ LANGUAGE plpgsql", "SELECT $$a;b$$"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitScript(tt.script)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, queries(got))
+		})
+	}
+}
+
+func Test_splitScript_line(t *testing.T) {
+	got, err := splitScript("# header\n\nCREATE TABLE a (\n  id int\n);\n\n/* x\n y */\nDROP TABLE a;")
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, 3, got[0].line)
+	assert.Equal(t, 9, got[1].line)
+}
+
+func Test_splitScript_unterminated(t *testing.T) {
+	_, err := splitScript("INSERT INTO a VALUES ('a);")
+	assert.Error(t, err)
+
+	_, err = splitScript("/* a; b")
+	assert.Error(t, err)
+}
+
+func TestImportSQLite(t *testing.T) {
+	useSQLite(t, t.TempDir())
+	schema := filepath.Join(t.TempDir(), "schema.sql")
+	require.NoError(t, os.WriteFile(schema, []byte(`-- users and an audit trigger
+CREATE TABLE users (id INTEGER PRIMARY KEY, name VARCHAR(50) NOT NULL DEFAULT '');
+CREATE TABLE audits (user_id INTEGER NOT NULL);
+CREATE INDEX idx_name ON users (name);
+CREATE VIEW user_names AS SELECT name FROM users;
+
+DELIMITER $$
+CREATE TRIGGER users_audit AFTER INSERT ON users
+BEGIN
+  INSERT INTO audits (user_id) VALUES (NEW.id);
+END$$
+DELIMITER ;
+
+INSERT INTO users (id, name) VALUES (1, 'a;b');
+`), 0644))
+
+	test, err := NewTestE(schema)
+	require.NoError(t, err)
+	defer test.Drop()
+
+	var ct int
+	require.NoError(t, test.DB().QueryRow("SELECT count(1) FROM audits").Scan(&ct))
+	assert.Equal(t, 1, ct)
+
+	var name string
+	require.NoError(t, test.DB().QueryRow("SELECT name FROM user_names").Scan(&name))
+	assert.Equal(t, "a;b", name)
+}
+
+func TestImportSQLite_error(t *testing.T) {
+	useSQLite(t, t.TempDir())
+	schema := filepath.Join(t.TempDir(), "schema.sql")
+	require.NoError(t, os.WriteFile(schema, []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);\n\nINSERT INTO missing VALUES (1);\n"), 0644))
+
+	_, err := NewTestE(schema)
+	var importErr *ImportError
+	require.True(t, errors.As(err, &importErr))
+	assert.Equal(t, 3, importErr.Line)
+	assert.Equal(t, "INSERT INTO missing VALUES (1)", importErr.SQL)
+}
@@ -0,0 +1,65 @@
+package dbunit
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// sharedDatabase is a test database prepared once per process and shared by
+// the tests running in transactions
+type sharedDatabase struct {
+	once sync.Once
+	test *Testing
+	err  error
+}
+
+var shared = struct {
+	sync.Mutex
+	m map[string]*sharedDatabase
+}{m: make(map[string]*sharedDatabase)}
+
+// sharedTest returns the database created from schema with fixtures loaded,
+// creating it on first use
+func sharedTest(config Config, schema string, fixtures []string) (*Testing, error) {
+	key := config.Driver + "|" + config.DSN + "|" + schema + "|" + strings.Join(fixtures, ",")
+
+	shared.Lock()
+	s, ok := shared.m[key]
+	if !ok {
+		s = &sharedDatabase{}
+		shared.m[key] = s
+	}
+	shared.Unlock()
+
+	s.once.Do(func() {
+		s.test, s.err = newTest(config, schema, "")
+		if s.err != nil {
+			return
+		}
+		if err := s.test.LoadE(fixtures...); err != nil {
+			_ = s.test.DropE()
+			s.test, s.err = nil, err
+		}
+	})
+	return s.test, s.err
+}
+
+// DropShared 删除本进程中 RunTx、NewTxDatabase 共用的测试库，可在 TestMain 中 m.Run() 之后调用。
+// 未调用时共用的测试库会遗留在服务器上，由 Reap 或 Config.ReapAfter 按创建时间清理
+func DropShared() error {
+	shared.Lock()
+	defer shared.Unlock()
+
+	var errs []error
+	for key, s := range shared.m {
+		if s.test != nil {
+			if err := s.test.DropE(); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		delete(shared.m, key)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,254 @@
+package dbunit
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxPlaceholders bounds the bind variables of a restore statement, 999 is
+// the smallest limit among the supported servers (SQLite before 3.32)
+const maxPlaceholders = 999
+
+// SnapshotID identifies a snapshot taken by Testing.Snapshot
+type SnapshotID int
+
+// snapshot is an in-memory image of the rows of every table
+type snapshot struct {
+	tables []*tableImage
+}
+
+type tableImage struct {
+	name    string
+	columns []string
+	rows    [][]interface{}
+	// sum tells whether the table changed since the snapshot, it is the
+	// server checksum when the dialect has one, a digest of the rows otherwise
+	sum string
+}
+
+// Snapshot captures the content of all the tables of the test database, it panics on any error
+func (d *Testing) Snapshot() SnapshotID {
+	id, err := d.SnapshotE()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// SnapshotE captures the content of all the tables of the test database,
+// Restore brings the database back to it later
+func (d *Testing) SnapshotE() (SnapshotID, error) {
+	tables, err := d.tdb.dialect.tableNames(d.db)
+	if err != nil {
+		return 0, fmt.Errorf("dbunit: snapshot database %s: %w", d.tdb.Name, err)
+	}
+
+	var sums map[string]string
+	if c, ok := d.tdb.dialect.(checksummer); ok && len(tables) > 0 {
+		if sums, err = c.checksum(d.db, tables); err != nil {
+			return 0, fmt.Errorf("dbunit: snapshot database %s: %w", d.tdb.Name, err)
+		}
+	}
+
+	s := &snapshot{}
+	for _, table := range tables {
+		img, err := d.readTable(table)
+		if err != nil {
+			return 0, fmt.Errorf("dbunit: snapshot table %s: %w", table, err)
+		}
+		if sums != nil {
+			img.sum = sums[table]
+		}
+		s.tables = append(s.tables, img)
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.snapshots = append(d.snapshots, s)
+	return SnapshotID(len(d.snapshots)), nil
+}
+
+// Restore brings the tables back to the content captured by snapshot id, it panics on any error
+func (d *Testing) Restore(id SnapshotID) {
+	if err := d.RestoreE(id); err != nil {
+		panic(err)
+	}
+}
+
+// RestoreE brings the tables back to the content captured by snapshot id.
+// Only the tables modified since the snapshot are rewritten, in a single
+// transaction with the foreign keys unchecked. Auto increment counters and
+// sequences are left as they are.
+func (d *Testing) RestoreE(id SnapshotID) error {
+	d.mu.Lock()
+	if id < 1 || int(id) > len(d.snapshots) {
+		d.mu.Unlock()
+		return fmt.Errorf("dbunit: unknown snapshot %d", id)
+	}
+	s := d.snapshots[id-1]
+	d.mu.Unlock()
+
+	changed, err := d.changedTables(s)
+	if err != nil {
+		return fmt.Errorf("dbunit: restore database %s: %w", d.tdb.Name, err)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := d.tdb.dialect.disableForeignKeys(tx); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("dbunit: restore database %s: %w", d.tdb.Name, err)
+	}
+	for _, img := range changed {
+		if err := d.writeTable(tx, img); err != nil {
+			_ = d.tdb.dialect.enableForeignKeys(tx)
+			_ = tx.Rollback()
+			return fmt.Errorf("dbunit: restore table %s: %w", img.name, err)
+		}
+	}
+	if err := d.tdb.dialect.enableForeignKeys(tx); err != nil {
+		_ = tx.Rollback()
+		return fmt.Errorf("dbunit: restore database %s: %w", d.tdb.Name, err)
+	}
+	return tx.Commit()
+}
+
+// changedTables returns the tables of s whose content differs from the image
+func (d *Testing) changedTables(s *snapshot) ([]*tableImage, error) {
+	var changed []*tableImage
+
+	if c, ok := d.tdb.dialect.(checksummer); ok {
+		tables := make([]string, len(s.tables))
+		for i, img := range s.tables {
+			tables[i] = img.name
+		}
+		if len(tables) == 0 {
+			return nil, nil
+		}
+		sums, err := c.checksum(d.db, tables)
+		if err != nil {
+			return nil, err
+		}
+		for _, img := range s.tables {
+			if sums[img.name] != img.sum {
+				changed = append(changed, img)
+			}
+		}
+		return changed, nil
+	}
+
+	for _, img := range s.tables {
+		current, err := d.readTable(img.name)
+		if err != nil {
+			return nil, err
+		}
+		if current.sum != img.sum {
+			changed = append(changed, img)
+		}
+	}
+	return changed, nil
+}
+
+func (d *Testing) readTable(table string) (*tableImage, error) {
+	rows, err := d.db.Query("SELECT * FROM " + d.tdb.dialect.quote(table))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	img := &tableImage{name: table, columns: columns}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		dest := make([]interface{}, len(columns))
+		for i := range values {
+			dest[i] = &values[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		img.rows = append(img.rows, values)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	img.sum = img.digest()
+	return img, nil
+}
+
+// digest hashes the rows regardless of the order the server returned them in
+func (img *tableImage) digest() string {
+	rows := make([]string, len(img.rows))
+	for i, row := range img.rows {
+		h := sha256.New()
+		for _, v := range row {
+			fmt.Fprintf(h, "%T:%v\x00", v, v)
+		}
+		rows[i] = string(h.Sum(nil))
+	}
+	sort.Strings(rows)
+
+	h := sha256.New()
+	for _, row := range rows {
+		h.Write([]byte(row))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeTable replaces the rows of the table with the image. DELETE is used
+// rather than TRUNCATE, which commits the transaction on MySQL.
+func (d *Testing) writeTable(tx *sql.Tx, img *tableImage) error {
+	dialect := d.tdb.dialect
+	if _, err := tx.Exec("DELETE FROM " + dialect.quote(img.name)); err != nil {
+		return err
+	}
+	if len(img.rows) == 0 || len(img.columns) == 0 {
+		return nil
+	}
+
+	columns := make([]string, len(img.columns))
+	for i, c := range img.columns {
+		columns[i] = dialect.quote(c)
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", dialect.quote(img.name), strings.Join(columns, ", "))
+
+	batch := maxPlaceholders / len(img.columns)
+	if batch < 1 {
+		batch = 1
+	}
+	for start := 0; start < len(img.rows); start += batch {
+		end := start + batch
+		if end > len(img.rows) {
+			end = len(img.rows)
+		}
+
+		var (
+			values = make([]string, 0, end-start)
+			args   = make([]interface{}, 0, (end-start)*len(img.columns))
+		)
+		for _, row := range img.rows[start:end] {
+			binds := make([]string, len(row))
+			for i, v := range row {
+				args = append(args, v)
+				binds[i] = dialect.placeholder(len(args))
+			}
+			values = append(values, "("+strings.Join(binds, ", ")+")")
+		}
+		if _, err := tx.Exec(prefix+strings.Join(values, ", "), args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package dbunit
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTesting_SnapshotRestore(t *testing.T) {
+	useSQLite(t, t.TempDir())
+
+	New(t, func(d *DBUnit) {
+		test := d.NewTesting("testdata/sqlite/schema.sql", "testdata/fixtures")
+		db := test.DB()
+
+		id := test.Snapshot()
+		assert.Equal(t, SnapshotID(1), id)
+
+		// nothing changed, nothing to restore
+		changed, err := test.changedTables(test.snapshots[0])
+		require.NoError(t, err)
+		assert.Empty(t, changed)
+
+		_, err = db.Exec("delete from members")
+		require.NoError(t, err)
+		_, err = db.Exec("update users set email = 'changed@test.cn' where id = 1")
+		require.NoError(t, err)
+
+		changed, err = test.changedTables(test.snapshots[0])
+		require.NoError(t, err)
+		var names []string
+		for _, img := range changed {
+			names = append(names, img.name)
+		}
+		assert.Equal(t, []string{"members", "users"}, names)
+
+		test.Restore(id)
+
+		var email string
+		require.NoError(t, db.QueryRow("select email from users where id = 1").Scan(&email))
+		assert.Equal(t, "test@test.cn", email)
+
+		var ct int
+		require.NoError(t, db.QueryRow("select count(1) from members").Scan(&ct))
+		assert.Equal(t, 2, ct)
+
+		changed, err = test.changedTables(test.snapshots[0])
+		require.NoError(t, err)
+		assert.Empty(t, changed)
+	})
+}
+
+func TestTesting_RestoreUnknown(t *testing.T) {
+	useSQLite(t, t.TempDir())
+
+	New(t, func(d *DBUnit) {
+		test := d.NewTesting("testdata/sqlite/schema.sql", "testdata/fixtures/users.yml")
+		assert.EqualError(t, test.RestoreE(3), "dbunit: unknown snapshot 3")
+	})
+}
@@ -0,0 +1,185 @@
+package dbunit
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sqliteMemory is the data source that keeps every test database in memory
+const sqliteMemory = ":memory:"
+
+// isSQLiteMemory tells whether the test databases of config are in-memory
+// SQLite databases, which can not be cloned from a template
+func isSQLiteMemory(d dialect, config Config) bool {
+	_, ok := d.(*sqliteDialect)
+	return ok && config.DSN == sqliteMemory
+}
+
+// sqliteDialect stores each test database in its own file under the
+// directory given as data source, or in a shared cache in-memory database
+// when the data source is ":memory:".
+type sqliteDialect struct{}
+
+func (*sqliteDialect) dsn(source, name string, params url.Values) string {
+	if source == sqliteMemory {
+		dsn := "file:" + name + "?mode=memory&cache=shared"
+		if len(params) > 0 {
+			dsn += "&" + params.Encode()
+		}
+		return dsn
+	}
+
+	if source == "" {
+		source = os.TempDir()
+	}
+	dsn := filepath.Join(source, name+".db")
+	if len(params) > 0 {
+		dsn += "?" + params.Encode()
+	}
+	return dsn
+}
+
+// params sets the location times are read in, only go-sqlite3 supports it
+func (*sqliteDialect) params(c Config) url.Values {
+	params := url.Values{}
+	if c.Driver == "sqlite3" && c.TimeZone != "" {
+		params.Set("_loc", c.TimeZone)
+	}
+	return params
+}
+
+// serverDSN connects straight to the test database, SQLite has no server
+// to manage databases through
+func (d *sqliteDialect) serverDSN(source, name string) string {
+	return d.dsn(source, name, nil)
+}
+
+func (*sqliteDialect) createDatabase(db *sql.DB, name string) error {
+	defaultLog.Print("Create SQLite database " + name)
+	// the file, or the shared in-memory database, comes into existence with
+	// the first connection and lives as long as this pool keeps one open
+	return db.Ping()
+}
+
+func (*sqliteDialect) dropDatabase(db *sql.DB, name string) error {
+	var file string
+	err := db.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main'").Scan(&file)
+	if err != nil {
+		return err
+	}
+
+	defaultLog.Print("Drop SQLite database " + name)
+	// an in-memory database vanishes once its last connection is closed
+	if file == "" {
+		return nil
+	}
+
+	for _, f := range []string{file, file + "-journal", file + "-wal", file + "-shm"} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// cloneDatabase copies the file of the template, in-memory databases can
+// not be copied
+func (d *sqliteDialect) cloneDatabase(db *sql.DB, c Config, template, name string) error {
+	if c.DSN == sqliteMemory {
+		return errCloneUnsupported
+	}
+
+	src, err := os.Open(d.dsn(c.DSN, template, nil))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(d.dsn(c.DSN, name, nil), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return db.Ping()
+}
+
+// renameDatabase renames the file of the database, in-memory databases
+// vanish with their last connection and can not be kept
+func (d *sqliteDialect) renameDatabase(_ *sql.DB, c Config, name, newName string) error {
+	if c.DSN == sqliteMemory {
+		return fmt.Errorf("dbunit: in-memory database %s can not be kept", name)
+	}
+	file, newFile := d.dsn(c.DSN, name, nil), d.dsn(c.DSN, newName, nil)
+	// os.Rename replaces an existing file
+	if _, err := os.Stat(newFile); err == nil {
+		return fmt.Errorf("dbunit: database %s already exists", newName)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		if err := os.Rename(file+suffix, newFile+suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.Rename(file, newFile)
+}
+
+func (d *sqliteDialect) clientCommand(source, name string) string {
+	return "sqlite3 " + d.dsn(source, name, nil)
+}
+
+// listDatabases looks for the database files in the directory of source,
+// in-memory databases can not be seen from another process
+func (*sqliteDialect) listDatabases(_ *sql.DB, source string) ([]string, error) {
+	if source == sqliteMemory {
+		return nil, nil
+	}
+	if source == "" {
+		source = os.TempDir()
+	}
+	files, err := filepath.Glob(filepath.Join(source, "test_*.db"))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = strings.TrimSuffix(filepath.Base(file), ".db")
+	}
+	return names, nil
+}
+
+func (*sqliteDialect) tableNames(db *sql.DB) ([]string, error) {
+	return queryStrings(db, "SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name")
+}
+
+func (*sqliteDialect) quote(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func (*sqliteDialect) placeholder(int) string {
+	return "?"
+}
+
+// disableForeignKeys defers the checks to the commit, PRAGMA foreign_keys
+// has no effect inside a transaction
+func (*sqliteDialect) disableForeignKeys(tx *sql.Tx) error {
+	_, err := tx.Exec("PRAGMA defer_foreign_keys = ON")
+	return err
+}
+
+// enableForeignKeys has nothing to undo, defer_foreign_keys is reset when
+// the transaction ends
+func (*sqliteDialect) enableForeignKeys(*sql.Tx) error {
+	return nil
+}
@@ -0,0 +1,84 @@
+package dbunit
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+// useSQLite switches the package to SQLite for the duration of the test
+func useSQLite(t *testing.T, source string) {
+	config := defaultConfig
+	SetDriver("sqlite3")
+	SetDatabase(source)
+	t.Cleanup(func() {
+		defaultConfig = config
+	})
+}
+
+func Test_sqliteDialect_dsn(t *testing.T) {
+	d := &sqliteDialect{}
+	assert.Equal(t, "file:test_1?mode=memory&cache=shared", d.dsn(":memory:", "test_1", nil))
+	assert.Equal(t, filepath.Join("tmp", "test_1.db"), d.dsn("tmp", "test_1", nil))
+	assert.Equal(t, filepath.Join(os.TempDir(), "test_1.db"), d.dsn("", "test_1", nil))
+
+	params := d.params(Config{Driver: "sqlite3", TimeZone: "UTC"})
+	assert.Equal(t, "file:test_1?mode=memory&cache=shared&_loc=UTC", d.dsn(":memory:", "test_1", params))
+	assert.Equal(t, filepath.Join("tmp", "test_1.db")+"?_loc=UTC", d.dsn("tmp", "test_1", params))
+}
+
+func TestRunSQLite(t *testing.T) {
+	sources := map[string]string{
+		"file":   t.TempDir(),
+		"memory": ":memory:",
+	}
+	for name, source := range sources {
+		t.Run(name, func(t *testing.T) {
+			useSQLite(t, source)
+			Run(t, "testdata/sqlite/schema.sql", func(t *testing.T, db *sql.DB) {
+				var email string
+				if err := db.QueryRow("select email from users where id = 1").Scan(&email); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, "test@test.cn", email)
+
+				var ct int
+				if err := db.QueryRow("select count(1) from members").Scan(&ct); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, 2, ct)
+			}, "testdata/fixtures", "testdata/custom")
+		})
+	}
+}
+
+func TestDropSQLite(t *testing.T) {
+	useSQLite(t, t.TempDir())
+	test := NewTest("testdata/sqlite/schema.sql")
+	file := test.tdb.dialect.serverDSN(test.tdb.config.DSN, test.tdb.Name)
+	assert.FileExists(t, file)
+
+	test.Drop()
+	assert.NoFileExists(t, file)
+}
+
+func Test_sqliteDialect_renameDatabase(t *testing.T) {
+	dir := t.TempDir()
+	d := &sqliteDialect{}
+	c := Config{Driver: "sqlite3", DSN: dir}
+	for _, name := range []string{"test_a", "test_b"} {
+		assert.NoError(t, os.WriteFile(d.dsn(dir, name, nil), []byte(name), 0644))
+	}
+
+	assert.EqualError(t, d.renameDatabase(nil, c, "test_a", "test_b"), "dbunit: database test_b already exists")
+	content, err := os.ReadFile(d.dsn(dir, "test_b", nil))
+	assert.NoError(t, err)
+	assert.Equal(t, "test_b", string(content))
+
+	assert.NoError(t, d.renameDatabase(nil, c, "test_a", "test_c"))
+	assert.FileExists(t, d.dsn(dir, "test_c", nil))
+}
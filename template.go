@@ -0,0 +1,91 @@
+package dbunit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"sync"
+)
+
+// errCloneUnsupported is returned by dialects that can not clone the
+// template, the schema is imported into the test database instead
+var errCloneUnsupported = errors.New("dbunit: clone database unsupported")
+
+// schemaTemplate is a database built once per process from a schema file,
+// the test databases are cloned from it
+type schemaTemplate struct {
+	once sync.Once
+	tdb  *database
+	err  error
+	// unsupported is set once the dialect refused to clone the template
+	unsupported bool
+}
+
+var templates = struct {
+	sync.Mutex
+	m map[string]*schemaTemplate
+}{m: make(map[string]*schemaTemplate)}
+
+// templateFor returns the template of schema on the server of config,
+// building it on first use
+func templateFor(config Config, schema string) (*schemaTemplate, error) {
+	content, err := ioutil.ReadFile(schema)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(content)
+	key := config.Driver + "|" + config.DSN + "|" + hex.EncodeToString(sum[:])
+
+	templates.Lock()
+	tpl, ok := templates.m[key]
+	if !ok {
+		tpl = &schemaTemplate{}
+		templates.m[key] = tpl
+	}
+	templates.Unlock()
+
+	tpl.once.Do(func() {
+		config.NoTemplate = Bool(true)
+		tpl.tdb, tpl.err = newDatabaseWithName(config, newDatabaseName("")+"_tpl", schema)
+	})
+	return tpl, tpl.err
+}
+
+// clone creates database name as a copy of the template
+func (tpl *schemaTemplate) clone(db *database) error {
+	templates.Lock()
+	unsupported := tpl.unsupported
+	templates.Unlock()
+	if unsupported {
+		return errCloneUnsupported
+	}
+
+	defaultLog.Print("Clone database " + db.Name + " from " + tpl.tdb.Name)
+	err := db.dialect.cloneDatabase(db.db, db.config, tpl.tdb.Name, db.Name)
+	if err == errCloneUnsupported {
+		templates.Lock()
+		tpl.unsupported = true
+		templates.Unlock()
+	}
+	return err
+}
+
+// DropTemplates 删除本进程创建的 schema 模板库，可在 TestMain 中 m.Run() 之后调用。
+// 未调用时模板库会遗留在服务器上，由 Reap 或 Config.ReapAfter 按创建时间清理
+func DropTemplates() error {
+	templates.Lock()
+	defer templates.Unlock()
+
+	var errs []error
+	for key, tpl := range templates.m {
+		if tpl.tdb != nil {
+			if err := tpl.tdb.Drop(); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		delete(templates.m, key)
+	}
+	return errors.Join(errs...)
+}
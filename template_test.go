@@ -0,0 +1,77 @@
+package dbunit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateSQLite(t *testing.T) {
+	dir := t.TempDir()
+	useSQLite(t, dir)
+	schema := filepath.Join(t.TempDir(), "schema.sql")
+	writeFile(t, schema, "CREATE TABLE roles (id INTEGER PRIMARY KEY, name VARCHAR(20) NOT NULL);\nINSERT INTO roles VALUES (1, 'admin');\n")
+
+	test1, err := NewTestE(schema)
+	require.NoError(t, err)
+	defer test1.Drop()
+	test2, err := NewTestE(schema)
+	require.NoError(t, err)
+	defer test2.Drop()
+
+	tpl, err := templateFor(defaultConfig, schema)
+	require.NoError(t, err)
+	assert.False(t, tpl.unsupported)
+
+	for _, test := range []*Testing{test1, test2} {
+		var name string
+		require.NoError(t, test.DB().QueryRow("SELECT name FROM roles WHERE id = 1").Scan(&name))
+		assert.Equal(t, "admin", name)
+	}
+
+	// the clones are independent
+	_, err = test1.DB().Exec("DELETE FROM roles")
+	require.NoError(t, err)
+	var ct int
+	require.NoError(t, test2.DB().QueryRow("SELECT count(1) FROM roles").Scan(&ct))
+	assert.Equal(t, 1, ct)
+
+	files, _ := filepath.Glob(filepath.Join(dir, "test_*_tpl.db"))
+	assert.Len(t, files, 1)
+	require.NoError(t, DropTemplates())
+	files, _ = filepath.Glob(filepath.Join(dir, "test_*_tpl.db"))
+	assert.Len(t, files, 0)
+}
+
+func TestTemplateSQLiteMemory(t *testing.T) {
+	useSQLite(t, sqliteMemory)
+	test, err := NewTestE("testdata/sqlite/schema.sql")
+	require.NoError(t, err)
+	defer test.Drop()
+	require.NoError(t, test.LoadE("testdata/custom"))
+
+	// no template is built for nothing
+	templates.Lock()
+	defer templates.Unlock()
+	for key := range templates.m {
+		assert.False(t, strings.HasPrefix(key, "sqlite3|"+sqliteMemory+"|"), key)
+	}
+}
+
+func TestNoTemplate(t *testing.T) {
+	dir := t.TempDir()
+	useSQLite(t, dir)
+	defaultConfig.NoTemplate = Bool(true)
+
+	test, err := NewTestE("testdata/sqlite/schema.sql")
+	require.NoError(t, err)
+	defer test.Drop()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
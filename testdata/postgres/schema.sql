@@ -0,0 +1,54 @@
+-- PostgreSQL schema of the tables used by testdata/fixtures and testdata/custom
+
+DROP TABLE IF EXISTS members;
+DROP TABLE IF EXISTS documents;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS custom;
+
+CREATE TABLE users (
+  id SERIAL PRIMARY KEY,
+  user_name VARCHAR(50) NOT NULL DEFAULT '' UNIQUE,
+  email VARCHAR(100) NOT NULL DEFAULT '' UNIQUE,
+  real_name VARCHAR(50) NOT NULL DEFAULT '',
+  password VARCHAR(64) NOT NULL DEFAULT '',
+  avatar VARCHAR(100) NOT NULL DEFAULT '',
+  status SMALLINT NOT NULL DEFAULT 1,
+  about VARCHAR(255) NOT NULL DEFAULT '',
+  role VARCHAR(30) NOT NULL DEFAULT 'user',
+  organization VARCHAR(50) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE documents (
+  id SERIAL PRIMARY KEY,
+  user_id INTEGER NOT NULL,
+  last_user_id INTEGER NOT NULL,
+  title VARCHAR(255) NOT NULL DEFAULT '',
+  domain VARCHAR(50) NOT NULL DEFAULT '' UNIQUE,
+  logo VARCHAR(100) NOT NULL DEFAULT '',
+  description VARCHAR(255) NOT NULL DEFAULT '',
+  permission SMALLINT NOT NULL DEFAULT 1,
+  password VARCHAR(64) NOT NULL DEFAULT '',
+  status SMALLINT NOT NULL DEFAULT 1,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
+
+-- not DEFERRABLE and members.yml references a missing user, the fixtures
+-- only load as a superuser, who skips the foreign key triggers
+CREATE TABLE members (
+  id SERIAL PRIMARY KEY,
+  doc_id INTEGER NOT NULL REFERENCES documents (id),
+  user_id INTEGER NOT NULL REFERENCES users (id),
+  UNIQUE (doc_id, user_id)
+);
+
+CREATE TABLE custom (
+  id SERIAL PRIMARY KEY,
+  name VARCHAR(255) NOT NULL UNIQUE,
+  nick_name VARCHAR(255) NOT NULL UNIQUE,
+  status SMALLINT NOT NULL DEFAULT 1,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,55 @@
+-- SQLite schema of the tables used by testdata/fixtures and testdata/custom
+
+DROP TABLE IF EXISTS `documents`;
+
+CREATE TABLE `documents` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `user_id` INTEGER NOT NULL,
+  `last_user_id` INTEGER NOT NULL,
+  `title` VARCHAR(255) NOT NULL DEFAULT '',
+  `domain` VARCHAR(50) NOT NULL DEFAULT '' UNIQUE,
+  `logo` VARCHAR(100) NOT NULL DEFAULT '',
+  `description` VARCHAR(255) NOT NULL DEFAULT '',
+  `permission` INTEGER NOT NULL DEFAULT 1,
+  `password` VARCHAR(64) NOT NULL DEFAULT '',
+  `status` INTEGER NOT NULL DEFAULT 1,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+
+DROP TABLE IF EXISTS `members`;
+
+CREATE TABLE `members` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `doc_id` INTEGER NOT NULL REFERENCES `documents` (`id`),
+  `user_id` INTEGER NOT NULL REFERENCES `users` (`id`),
+  UNIQUE (`doc_id`, `user_id`)
+);
+
+DROP TABLE IF EXISTS `users`;
+
+CREATE TABLE `users` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `user_name` VARCHAR(50) NOT NULL DEFAULT '' UNIQUE,
+  `email` VARCHAR(100) NOT NULL DEFAULT '' UNIQUE,
+  `real_name` VARCHAR(50) NOT NULL DEFAULT '',
+  `password` VARCHAR(64) NOT NULL DEFAULT '',
+  `avatar` VARCHAR(100) NOT NULL DEFAULT '',
+  `status` INTEGER NOT NULL DEFAULT 1,
+  `about` VARCHAR(255) NOT NULL DEFAULT '',
+  `role` VARCHAR(30) NOT NULL DEFAULT 'user',
+  `organization` VARCHAR(50) NOT NULL DEFAULT '',
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
+
+DROP TABLE IF EXISTS `custom`;
+
+CREATE TABLE `custom` (
+  `id` INTEGER PRIMARY KEY AUTOINCREMENT,
+  `name` VARCHAR(255) NOT NULL UNIQUE,
+  `nick_name` VARCHAR(255) NOT NULL UNIQUE,
+  `status` TINYINT NOT NULL DEFAULT 1,
+  `created_at` DATETIME NOT NULL,
+  `updated_at` DATETIME NOT NULL
+);
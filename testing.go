@@ -1,10 +1,14 @@
 package dbunit
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"os"
 	"strings"
+	"sync"
+	"testing"
+	"time"
 
 	"github.com/goapt/dbunit/fixtures"
 )
@@ -13,24 +17,60 @@ type Testing struct {
 	tdb    *database
 	db     *sql.DB
 	schema string
+
+	// pinned holds the transaction subtests run in, see Subtest
+	mu       sync.Mutex
+	pinnedDB *sql.DB
+	pinned   *txConnector
+
+	snapshots []*snapshot
 }
 
+// NewTest creates a test database from schema, it panics on any error
 func NewTest(schema string) *Testing {
-	tdb := newDatabase(schema)
+	test, err := NewTestE(schema)
+	if err != nil {
+		panic(err)
+	}
+	return test
+}
+
+// NewTestE creates a test database from schema
+func NewTestE(schema string) (*Testing, error) {
+	return newTest(defaultConfig, schema, "")
+}
+
+// newTest creates a test database for test testName, which may be empty
+func newTest(config Config, schema, testName string) (*Testing, error) {
+	if configErr != nil {
+		return nil, configErr
+	}
+
+	tdb, err := newDatabase(config, schema, testName)
+	if err != nil {
+		return nil, err
+	}
 
 	// Open connection to the test database.
 	// Do NOT import fixtures in a production database!
 	// Existing data would be deleted.
-	db, err := sql.Open("mysql", tdb.DSN())
+	db, err := sql.Open(tdb.config.Driver, tdb.DSN())
 	if err != nil {
-		panic("test mysql open fail " + err.Error())
+		_ = tdb.Drop()
+		return nil, fmt.Errorf("test database open fail,%w", err)
 	}
 
-	return &Testing{
-		tdb,
-		db,
-		schema,
+	if err := writeMetadata(db, tdb.dialect, newMetadata(testName)); err != nil {
+		_ = db.Close()
+		_ = tdb.Drop()
+		return nil, fmt.Errorf("test database write metadata fail,%w", err)
 	}
+
+	return &Testing{
+		tdb:    tdb,
+		db:     db,
+		schema: schema,
+	}, nil
 }
 
 func (d *Testing) DB() *sql.DB {
@@ -41,16 +81,111 @@ func (d *Testing) Schema() string {
 	return d.schema
 }
 
+// TxDB opens a handle on the test database whose changes are all made in a
+// single transaction, rollback discards them and closes the handle
+func (d *Testing) TxDB() (db *sql.DB, rollback func() error) {
+	return openTxDB(d.db.Driver(), d.tdb.DSN())
+}
+
+// Subtest runs f as subtest name of t. Every subtest works on the same pinned
+// connection inside a savepoint rolled back when the subtest ends, so the
+// fixtures are loaded once for all of them. The pinned transaction itself is
+// rolled back when t ends. Subtests must not call t.Parallel.
+func (d *Testing) Subtest(t *testing.T, name string, f func(t *testing.T, db *sql.DB)) bool {
+	t.Helper()
+
+	d.mu.Lock()
+	if d.pinned == nil {
+		d.pinnedDB, d.pinned = openTxConnector(d.db.Driver(), d.tdb.DSN())
+		t.Cleanup(d.unpin)
+	}
+	db, pinned := d.pinnedDB, d.pinned
+	d.mu.Unlock()
+
+	return t.Run(name, func(t *testing.T) {
+		sp, err := pinned.begin(context.Background())
+		if err != nil {
+			t.Fatalf("dbunit: savepoint on database %s: %v", d.tdb.Name, err)
+		}
+		t.Cleanup(func() {
+			if err := sp.discard(); err != nil {
+				t.Errorf("dbunit: rollback to savepoint on database %s: %v", d.tdb.Name, err)
+			}
+		})
+		f(t, db)
+	})
+}
+
+// unpin rolls back the transaction of the subtests
+func (d *Testing) unpin() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.pinned != nil {
+		_ = d.pinnedDB.Close()
+		_ = d.pinned.rollback()
+		d.pinnedDB, d.pinned = nil, nil
+	}
+}
+
+// DSN returns the data source name of the test database
+func (d *Testing) DSN() string {
+	return d.tdb.DSN()
+}
+
+// Drop drops the test database, it panics on any error
 func (d *Testing) Drop() {
+	if err := d.DropE(); err != nil {
+		panic(err)
+	}
+}
+
+// DropE drops the test database
+func (d *Testing) DropE() error {
+	// close our own connections first, some servers refuse to drop a database in use
+	d.unpin()
+	_ = d.db.Close()
 	err := d.tdb.Drop()
 	if err != nil {
-		panic("drop database error " + err.Error())
+		return fmt.Errorf("drop database error,%w", err)
 	}
+	return nil
 }
 
+// close releases the connections but keeps the test database
+func (d *Testing) close() {
+	d.unpin()
+	_ = d.db.Close()
+	_ = d.tdb.db.Close()
+}
+
+// keepFailed releases the connections and renames the database after the
+// failed test so it is easy to find
+func (d *Testing) keepFailed(testName string) error {
+	d.unpin()
+	_ = d.db.Close()
+	defer d.tdb.db.Close()
+	return d.tdb.rename(failedDatabaseName(testName, time.Now()))
+}
+
+// Load loads the fixture files and directories, it panics on any error
 func (d *Testing) Load(files ...string) {
+	if err := d.LoadE(files...); err != nil {
+		panic(err)
+	}
+}
+
+// LoadE loads the fixture files and directories
+func (d *Testing) LoadE(files ...string) error {
 	options := make([]func(*fixtures.Loader) error, 0)
 	options = append(options, fixtures.Database(d.db)) // You database connection
+	options = append(options, fixtures.Dialect(d.tdb.config.Driver))
+
+	loc, err := d.tdb.config.location()
+	if err != nil {
+		return err
+	}
+	options = append(options, fixtures.Location(loc))
 
 	fs := make([]string, 0)
 	for _, file := range files {
@@ -67,14 +202,12 @@ func (d *Testing) Load(files ...string) {
 
 	f, err := fixtures.New(options...)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
 	fmt.Printf("🐳 Load fixtures:%s\n", strings.Join(files, ","))
 
-	if err := f.Load(); err != nil {
-		panic(err)
-	}
+	return f.Load()
 }
 
 // isDir determines whether the specified path is a directory.
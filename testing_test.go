@@ -0,0 +1,37 @@
+package dbunit
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTesting_Subtest(t *testing.T) {
+	useSQLite(t, t.TempDir())
+
+	New(t, func(d *DBUnit) {
+		test := d.NewTesting("testdata/sqlite/schema.sql", "testdata/fixtures/users.yml")
+
+		for _, id := range []int{1, 2} {
+			test.Subtest(t, "delete", func(t *testing.T, db *sql.DB) {
+				assert.Equal(t, 2, countUsers(t, db))
+				_, err := db.Exec("delete from users where id = ?", id)
+				require.NoError(t, err)
+
+				// transactions of the code under test nest in the savepoint
+				tx, err := db.Begin()
+				require.NoError(t, err)
+				_, err = tx.Exec("delete from users")
+				require.NoError(t, err)
+				require.NoError(t, tx.Commit())
+				assert.Equal(t, 0, countUsers(t, db))
+			})
+		}
+
+		test.Subtest(t, "untouched", func(t *testing.T, db *sql.DB) {
+			assert.Equal(t, 2, countUsers(t, db))
+		})
+	})
+}
@@ -0,0 +1,370 @@
+package dbunit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// txConnector opens a single driver connection, begins a transaction on it
+// and hands out connections sharing that transaction. Transactions begun by
+// the code under test become savepoints, so rolling back the transaction
+// restores the database whatever happened meanwhile. All operations are
+// serialized and query results are buffered, the underlying connection can
+// only run one statement at a time.
+type txConnector struct {
+	drv driver.Driver
+	dsn string
+
+	mu        sync.Mutex
+	conn      driver.Conn
+	tx        driver.Tx
+	savepoint int
+}
+
+var (
+	_ driver.Connector          = &txConnector{}
+	_ driver.ConnBeginTx        = &txConn{}
+	_ driver.ExecerContext      = &txConn{}
+	_ driver.QueryerContext     = &txConn{}
+	_ driver.NamedValueChecker  = &txConn{}
+	_ driver.StmtExecContext    = &txStmt{}
+	_ driver.StmtQueryContext   = &txStmt{}
+	_ driver.NamedValueChecker  = &txStmt{}
+	_ driver.Rows               = &bufferedRows{}
+	_ driver.ConnPrepareContext = &txConn{}
+)
+
+// openTxDB returns a handle on the database of dsn whose changes are all
+// discarded by rollback
+func openTxDB(drv driver.Driver, dsn string) (*sql.DB, func() error) {
+	db, c := openTxConnector(drv, dsn)
+	return db, func() error {
+		_ = db.Close()
+		return c.rollback()
+	}
+}
+
+func openTxConnector(drv driver.Driver, dsn string) (*sql.DB, *txConnector) {
+	c := &txConnector{drv: drv, dsn: dsn}
+	return sql.OpenDB(c), c
+}
+
+func (c *txConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.open(ctx); err != nil {
+		return nil, err
+	}
+	return &txConn{c: c}, nil
+}
+
+// open connects and begins the transaction once, the caller holds the lock
+func (c *txConnector) open(ctx context.Context) error {
+	if c.conn != nil {
+		return nil
+	}
+	conn, err := c.drv.Open(c.dsn)
+	if err != nil {
+		return err
+	}
+	tx, err := beginTx(ctx, conn)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	c.conn, c.tx = conn, tx
+	return nil
+}
+
+// begin creates a savepoint in the transaction
+func (c *txConnector) begin(ctx context.Context) (*txSavepoint, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := c.open(ctx); err != nil {
+		return nil, err
+	}
+	c.savepoint++
+	sp := &txSavepoint{c: c, name: fmt.Sprintf("dbunit_sp_%d", c.savepoint)}
+	if err := c.exec(ctx, "SAVEPOINT "+sp.name); err != nil {
+		return nil, err
+	}
+	return sp, nil
+}
+
+func (c *txConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+func (c *txConnector) rollback() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	err := c.tx.Rollback()
+	if err2 := c.conn.Close(); err == nil {
+		err = err2
+	}
+	c.conn, c.tx = nil, nil
+	return err
+}
+
+func beginTx(ctx context.Context, conn driver.Conn) (driver.Tx, error) {
+	if b, ok := conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, driver.TxOptions{})
+	}
+	return conn.Begin()
+}
+
+// exec runs query on the underlying connection, the caller holds the lock
+func (c *txConnector) exec(ctx context.Context, query string) error {
+	if execer, ok := c.conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+	stmt, err := c.conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
+
+type txConn struct {
+	c *txConnector
+}
+
+func (t *txConn) Prepare(query string) (driver.Stmt, error) {
+	return t.PrepareContext(context.Background(), query)
+}
+
+func (t *txConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	var (
+		stmt driver.Stmt
+		err  error
+	)
+	if p, ok := t.c.conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = t.c.conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &txStmt{c: t.c, stmt: stmt}, nil
+}
+
+// Close keeps the underlying connection, it is closed by rollback
+func (t *txConn) Close() error {
+	return nil
+}
+
+func (t *txConn) Begin() (driver.Tx, error) {
+	return t.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (t *txConn) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+	return t.c.begin(ctx)
+}
+
+func (t *txConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	execer, ok := t.c.conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (t *txConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+
+	queryer, ok := t.c.conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return bufferRows(rows)
+}
+
+func (t *txConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := t.c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// txSavepoint is the transaction begun by the code under test
+type txSavepoint struct {
+	c    *txConnector
+	name string
+}
+
+func (s *txSavepoint) Commit() error {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	return s.c.exec(context.Background(), "RELEASE SAVEPOINT "+s.name)
+}
+
+func (s *txSavepoint) Rollback() error {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	return s.c.exec(context.Background(), "ROLLBACK TO SAVEPOINT "+s.name)
+}
+
+// discard rolls back to the savepoint and removes it
+func (s *txSavepoint) discard() error {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+
+	ctx := context.Background()
+	if err := s.c.exec(ctx, "ROLLBACK TO SAVEPOINT "+s.name); err != nil {
+		return err
+	}
+	return s.c.exec(ctx, "RELEASE SAVEPOINT "+s.name)
+}
+
+type txStmt struct {
+	c    *txConnector
+	stmt driver.Stmt
+}
+
+func (s *txStmt) Close() error {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	return s.stmt.Close()
+}
+
+func (s *txStmt) NumInput() int {
+	return s.stmt.NumInput()
+}
+
+func (s *txStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+	return s.stmt.Exec(args)
+}
+
+func (s *txStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+
+	rows, err := s.stmt.Query(args)
+	if err != nil {
+		return nil, err
+	}
+	return bufferRows(rows)
+}
+
+func (s *txStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+
+	if e, ok := s.stmt.(driver.StmtExecContext); ok {
+		return e.ExecContext(ctx, args)
+	}
+	return s.stmt.Exec(namedValuesToValues(args))
+}
+
+func (s *txStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	s.c.mu.Lock()
+	defer s.c.mu.Unlock()
+
+	var (
+		rows driver.Rows
+		err  error
+	)
+	if q, ok := s.stmt.(driver.StmtQueryContext); ok {
+		rows, err = q.QueryContext(ctx, args)
+	} else {
+		rows, err = s.stmt.Query(namedValuesToValues(args))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bufferRows(rows)
+}
+
+func (s *txStmt) CheckNamedValue(nv *driver.NamedValue) error {
+	if checker, ok := s.stmt.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	if checker, ok := s.c.conn.(driver.NamedValueChecker); ok {
+		return checker.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+func namedValuesToValues(args []driver.NamedValue) []driver.Value {
+	values := make([]driver.Value, len(args))
+	for i, arg := range args {
+		values[i] = arg.Value
+	}
+	return values
+}
+
+// bufferedRows holds a whole result set so the connection is free for the
+// next statement while the caller iterates
+type bufferedRows struct {
+	columns []string
+	rows    [][]driver.Value
+	pos     int
+}
+
+func bufferRows(rows driver.Rows) (driver.Rows, error) {
+	defer rows.Close()
+
+	b := &bufferedRows{columns: rows.Columns()}
+	for {
+		values := make([]driver.Value, len(b.columns))
+		if err := rows.Next(values); err != nil {
+			if err == io.EOF {
+				return b, nil
+			}
+			return nil, err
+		}
+		// drivers may reuse the memory of []byte values for the next row
+		for i, v := range values {
+			if v, ok := v.([]byte); ok {
+				values[i] = append([]byte(nil), v...)
+			}
+		}
+		b.rows = append(b.rows, values)
+	}
+}
+
+func (b *bufferedRows) Columns() []string {
+	return b.columns
+}
+
+func (b *bufferedRows) Close() error {
+	return nil
+}
+
+func (b *bufferedRows) Next(dest []driver.Value) error {
+	if b.pos >= len(b.rows) {
+		return io.EOF
+	}
+	copy(dest, b.rows[b.pos])
+	b.pos++
+	return nil
+}
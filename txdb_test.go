@@ -0,0 +1,77 @@
+package dbunit
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func countUsers(t *testing.T, db *sql.DB) int {
+	var ct int
+	require.NoError(t, db.QueryRow("select count(1) from users").Scan(&ct))
+	return ct
+}
+
+func TestRunTx(t *testing.T) {
+	useSQLite(t, t.TempDir())
+	t.Cleanup(func() {
+		assert.NoError(t, DropShared())
+	})
+
+	for i := 0; i < 2; i++ {
+		t.Run("rollback", func(t *testing.T) {
+			RunTx(t, "testdata/sqlite/schema.sql", func(t *testing.T, db *sql.DB) {
+				assert.Equal(t, 2, countUsers(t, db))
+				_, err := db.Exec("delete from users where id = ?", 1)
+				require.NoError(t, err)
+				assert.Equal(t, 1, countUsers(t, db))
+			}, "testdata/fixtures/users.yml")
+		})
+	}
+}
+
+func TestRunTx_begin(t *testing.T) {
+	useSQLite(t, t.TempDir())
+	t.Cleanup(func() {
+		assert.NoError(t, DropShared())
+	})
+
+	RunTx(t, "testdata/sqlite/schema.sql", func(t *testing.T, db *sql.DB) {
+		tx, err := db.Begin()
+		require.NoError(t, err)
+		_, err = tx.Exec("delete from users")
+		require.NoError(t, err)
+		require.NoError(t, tx.Rollback())
+		assert.Equal(t, 2, countUsers(t, db))
+
+		tx, err = db.Begin()
+		require.NoError(t, err)
+		_, err = tx.Exec("delete from users where id = 2")
+		require.NoError(t, err)
+		// queries outside the transaction do not deadlock
+		assert.Equal(t, 1, countUsers(t, db))
+		require.NoError(t, tx.Commit())
+		assert.Equal(t, 1, countUsers(t, db))
+
+		rows, err := db.Query("select id, email from users")
+		require.NoError(t, err)
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				id    int
+				email string
+			)
+			require.NoError(t, rows.Scan(&id, &email))
+			_, err = db.Exec("update users set status = 2 where id = ?", id)
+			require.NoError(t, err)
+		}
+		require.NoError(t, rows.Err())
+	}, "testdata/fixtures/users.yml")
+
+	// another handle does not see the uncommitted changes of the first one
+	RunTx(t, "testdata/sqlite/schema.sql", func(t *testing.T, db *sql.DB) {
+		assert.Equal(t, 2, countUsers(t, db))
+	}, "testdata/fixtures/users.yml")
+}
@@ -1,32 +1,78 @@
 package dbunit
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/goapt/dbunit/fixtures"
+	"github.com/goapt/dbunit/internal/yamljson"
 )
 
+// PluckWithFixture plucks key from every record of a fixture file, it panics on any error
 func PluckWithFixture(filePath string, key string) []interface{} {
+	s, err := PluckWithFixtureE(filePath, key)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// PluckWithFixtureE plucks key from every record of a fixture file
+func PluckWithFixtureE(filePath string, key string) ([]interface{}, error) {
 	var data = make([]map[string]interface{}, 0)
 	if !isExists(filePath) {
-		panic("file not exists:" + filePath)
+		return nil, errors.New("file not exists:" + filePath)
+	}
+	d, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
 	}
-	d, _ := ioutil.ReadFile(filePath)
 	tpl := fixtures.NewTemplate()
-	d, err := tpl.Parse(d)
+	d, err = tpl.Parse(d)
 	if err != nil {
-		panic(err)
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(filePath), ".json") {
+		err = unmarshalJSONRecords(d, &data)
+	} else {
+		err = yaml.Unmarshal(d, &data)
 	}
-	err = yaml.Unmarshal(d, &data)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	return Pluck(data, key)
+	return Pluck(data, key), nil
+}
+
+// unmarshalJSONRecords decodes the records of a JSON fixture file like the
+// loader does, into the values YAML records have
+func unmarshalJSONRecords(d []byte, data *[]map[string]interface{}) error {
+	doc, _, err := yamljson.Decode(d)
+	if err != nil || doc == nil {
+		return err
+	}
+	items, ok := doc.([]interface{})
+	if !ok {
+		return errors.New("json fixture is not an array of records")
+	}
+	for _, item := range items {
+		m, ok := item.(map[interface{}]interface{})
+		if !ok {
+			return errors.New("json fixture is not an array of records")
+		}
+		record := make(map[string]interface{}, len(m))
+		for k, v := range m {
+			record[k.(string)] = v
+		}
+		*data = append(*data, record)
+	}
+	return nil
 }
 
 func Pluck(data []map[string]interface{}, key string) []interface{} {
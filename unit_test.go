@@ -1,10 +1,13 @@
 package dbunit
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestPluckWithFixture(t *testing.T) {
@@ -32,3 +35,30 @@ func Test_unique(t *testing.T) {
 		t.Error("unique error")
 	}
 }
+
+func TestPluckWithFixtureE(t *testing.T) {
+	_, err := PluckWithFixtureE("./testdata/fixtures/not_exists.yml", "id")
+	assert.Error(t, err)
+}
+
+func TestPluckWithFixtureE_json(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[{"id": 1}, {"id": 2}]`), 0o644))
+	ids, err := PluckWithFixtureE(file, "id")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2}, ids)
+
+	// nested values are decoded like in a YAML file
+	yml := filepath.Join(t.TempDir(), "users.yml")
+	require.NoError(t, os.WriteFile(yml, []byte("- profile: {age: 3, tags: [a, 1.5]}\n"), 0o644))
+	require.NoError(t, os.WriteFile(file, []byte(`[{"profile": {"age": 3, "tags": ["a", 1.5]}}]`), 0o644))
+	want, err := PluckWithFixtureE(yml, "profile")
+	require.NoError(t, err)
+	got, err := PluckWithFixtureE(file, "profile")
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+
+	require.NoError(t, os.WriteFile(file, []byte(`{"id": 1}`), 0o644))
+	_, err = PluckWithFixtureE(file, "id")
+	assert.Error(t, err)
+}